@@ -0,0 +1,29 @@
+package main
+
+import "fmt"
+
+// Values for Config.ClipboardAfterPaste
+const (
+	afterPasteRestore         = "restore"
+	afterPasteKeepTranslation = "keep_translation"
+	afterPasteClear           = "clear"
+)
+
+// afterPasteModes are the values of Config.ClipboardAfterPaste, with their
+// tray labels
+var afterPasteModes = []struct {
+	name, label string
+}{
+	{afterPasteRestore, "Restore Previous Content"},
+	{afterPasteKeepTranslation, "Keep the Translation"},
+	{afterPasteClear, "Clear"},
+}
+
+// checkClipboardAfterPaste rejects unknown clipboard_after_paste values
+func checkClipboardAfterPaste(cfg *Config) error {
+	switch cfg.ClipboardAfterPaste {
+	case afterPasteRestore, afterPasteKeepTranslation, afterPasteClear:
+		return nil
+	}
+	return fmt.Errorf(`clipboard_after_paste must be "restore", "keep_translation" or "clear", got %q`, cfg.ClipboardAfterPaste)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// alternativeCount is how many candidate translations are asked for with
+// Config.ShowAlternatives, the primary one included
+const alternativeCount = 3
+
+// alternativesKey carries the alternatives of the translation under a
+// context
+type alternativesKey struct{}
+
+// alternatives collects the candidate translations after the primary one
+type alternatives struct {
+	texts []string
+}
+
+// withAlternatives asks for alternative translations under ctx, from
+// backends that can return several candidates. They are in the returned
+// alternatives once the translation is done; the last translation wins
+// when there are several, as in a chain.
+func withAlternatives(ctx context.Context) (context.Context, *alternatives) {
+	alts := &alternatives{}
+	return context.WithValue(ctx, alternativesKey{}, alts), alts
+}
+
+// alternativesFrom returns the alternatives set by withAlternatives
+func alternativesFrom(ctx context.Context) (*alternatives, bool) {
+	alts, ok := ctx.Value(alternativesKey{}).(*alternatives)
+	return alts, ok
+}
+
+// choiceIndex reads the number a choice dialog printed for the chosen
+// option, e.g. "2" or "2. text", as an index into n options
+func choiceIndex(out string, n int) (int, bool) {
+	number, _, _ := strings.Cut(strings.TrimSpace(out), ".")
+	i, err := strconv.Atoi(number)
+	if err != nil || i < 1 || i > n {
+		return 0, false
+	}
+	return i - 1, true
+}
@@ -0,0 +1,29 @@
+package main
+
+import "regexp"
+
+// Defaults for Config.AnnotateOpen and Config.AnnotateClose
+const (
+	defaultAnnotateOpen  = " ["
+	defaultAnnotateClose = "]"
+)
+
+// pastedText is what a hotkey translation pastes over original: the
+// translation, or with Config.AnnotateMode the original followed by the
+// bracketed translation, or else the bilingual layout
+func pastedText(cfg *Config, original, translated string) string {
+	if cfg.AnnotateMode {
+		return original + cfg.AnnotateOpen + translated + cfg.AnnotateClose
+	}
+	return bilingualText(cfg, original, translated)
+}
+
+// stripAnnotations removes every open…close annotation from text, leaving
+// only the originals
+func stripAnnotations(text, open, close string) string {
+	if open == "" || close == "" {
+		return text
+	}
+	pattern := regexp.MustCompile(`(?s)` + regexp.QuoteMeta(open) + `.*?` + regexp.QuoteMeta(close))
+	return pattern.ReplaceAllString(text, "")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// keyPool hands out API keys round-robin, skipping keys that were rate
+// limited until their cooldown has passed
+type keyPool struct {
+	keys     []string
+	cooldown time.Duration
+	counter  atomic.Uint64
+
+	mu        sync.Mutex
+	throttled map[int]time.Time // key index -> end of cooldown
+}
+
+func newKeyPool(keys []string, cooldown time.Duration) *keyPool {
+	return &keyPool{keys: keys, cooldown: cooldown, throttled: make(map[int]time.Time)}
+}
+
+// next returns the index and value of the next key that isn't throttled.
+// When every key is throttled it returns the one whose turn it is anyway.
+func (p *keyPool) next() (int, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	first := -1
+	for range p.keys {
+		i := int(p.counter.Add(1)-1) % len(p.keys)
+		if first < 0 {
+			first = i
+		}
+		if until, ok := p.throttled[i]; !ok || time.Now().After(until) {
+			delete(p.throttled, i)
+			return i, p.keys[i]
+		}
+	}
+	return first, p.keys[first]
+}
+
+// throttle rests the key at index i for the cooldown after a 429
+func (p *keyPool) throttle(i int) {
+	if len(p.keys) < 2 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.throttled[i] = time.Now().Add(p.cooldown)
+	slog.Warn(fmt.Sprintf("⚠️  API key #%d is rate limited, skipping it for %s", i+1, p.cooldown))
+}
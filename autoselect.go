@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// autoSelectKey marks a hotkey translation whose prompt is picked by the
+// language of the text
+type autoSelectKey struct{}
+
+// withAutoSelect asks for the prompt of the translation under ctx to be
+// picked by promptForText
+func withAutoSelect(ctx context.Context) context.Context {
+	return context.WithValue(ctx, autoSelectKey{}, true)
+}
+
+// autoSelecting reports whether withAutoSelect was applied to ctx
+func autoSelecting(ctx context.Context) bool {
+	on, _ := ctx.Value(autoSelectKey{}).(bool)
+	return on
+}
+
+// titleWord matches the words of a prompt title
+var titleWord = regexp.MustCompile(`\pL+`)
+
+// promptTitleLanguage returns the first language code in capitals in a
+// prompt title, such as "de" for "DE→EN", or "" when there is none.
+// Lowercase words such as "to" or "my" aren't taken for codes.
+func promptTitleLanguage(title string) string {
+	for _, word := range titleWord.FindAllString(title, -1) {
+		if len(word) == 2 && strings.ToUpper(word) == word {
+			return strings.ToLower(word)
+		}
+	}
+	return ""
+}
+
+// promptForLanguage returns the first of the user's prompts whose title
+// has the language code as its first code
+func promptForLanguage(prompts []Prompt, code string) (Prompt, bool) {
+	for _, p := range prompts {
+		if promptTitleLanguage(p.Title) == code {
+			return p, true
+		}
+	}
+	return Prompt{}, false
+}
+
+// promptForText detects the language of text and returns the prompt for
+// it, falling back to the default prompt
+func (t *TranslatorApp) promptForText(ctx context.Context, text string) Prompt {
+	code, err := t.detectLanguage(ctx, text)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  %v, using the default prompt", err))
+		return defaultPrompt
+	}
+	t.mu.Lock()
+	prompt, ok := promptForLanguage(t.config.Prompts, code)
+	t.mu.Unlock()
+	if !ok {
+		log.Printf("🌐 Detected %s, no prompt for it, using the default prompt", code)
+		return defaultPrompt
+	}
+	log.Printf("🌐 Detected %s, using %q", code, prompt.Title)
+	return prompt
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	launchAgentLabel = "com.gemini.translator"
+	runKey           = `HKCU\Software\Microsoft\Windows\CurrentVersion\Run`
+	runValue         = "LingoSnap"
+)
+
+const launchAgentPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+</dict>
+</plist>
+`
+
+const autostartDesktopEntry = `[Desktop Entry]
+Type=Application
+Name=LingoSnap
+Exec=%s
+X-GNOME-Autostart-enabled=true
+`
+
+// autostartPath returns the file that starts LingoSnap at login on macOS
+// and Linux
+func autostartPath() (string, error) {
+	if runtime.GOOS == "darwin" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate home directory: %w", err)
+		}
+		return filepath.Join(home, "Library", "LaunchAgents", launchAgentLabel+".plist"), nil
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "autostart", "lingosnap.desktop"), nil
+}
+
+// autostartEnabled reports whether LingoSnap is registered to start at
+// login
+func autostartEnabled() bool {
+	if runtime.GOOS == "windows" {
+		return exec.Command("reg", "query", runKey, "/v", runValue).Run() == nil
+	}
+	path, err := autostartPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// setAutostart registers the running executable to start at login, or
+// removes the registration
+func setAutostart(enabled bool) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		cmd := exec.Command("reg", "delete", runKey, "/v", runValue, "/f")
+		if enabled {
+			cmd = exec.Command("reg", "add", runKey, "/v", runValue, "/t", "REG_SZ", "/d", `"`+exe+`"`, "/f")
+		}
+		if out, err := cmd.CombinedOutput(); err != nil && (enabled || autostartEnabled()) {
+			return fmt.Errorf("failed to update %s: %w: %s", runKey, err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+
+	path, err := autostartPath()
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return nil
+	}
+
+	content := fmt.Sprintf(autostartDesktopEntry, desktopExecQuote(exe))
+	if runtime.GOOS == "darwin" {
+		content = fmt.Sprintf(launchAgentPlist, launchAgentLabel, xmlEscape(exe))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// desktopExecQuote quotes path for the Exec key of a .desktop file. The
+// string escaping of the file doubles backslashes once more.
+func desktopExecQuote(path string) string {
+	r := strings.NewReplacer(`\`, `\\\\`, `"`, `\"`, "`", "\\`", "$", `\$`)
+	return `"` + r.Replace(path) + `"`
+}
+
+// xmlEscape escapes s for use as XML text
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const backTranslatePrompt = `Translate this text back to %s.
+Return only the translation without any additional comments or explanations:`
+
+// backTranslate translates translated back into the language of original,
+// without recording it in the history
+func (t *TranslatorApp) backTranslate(ctx context.Context, original, translated string) (string, error) {
+	sourceLang, err := t.detectLanguage(ctx, original)
+	if err != nil {
+		return "", err
+	}
+	back, err := t.translate(ctx, fmt.Sprintf(backTranslatePrompt, sourceLang), translated)
+	if err != nil {
+		return "", fmt.Errorf("back-translation failed: %w", err)
+	}
+	return back, nil
+}
+
+// diffOp is one word of a word-level diff
+type diffOp struct {
+	Kind byte // ' ' kept, '-' deleted, '+' inserted
+	Word string
+}
+
+// wordDiff compares a and b word by word using their longest common
+// subsequence
+func wordDiff(a, b string) []diffOp {
+	x, y := strings.Fields(a), strings.Fields(b)
+
+	// lcs[i][j] is the LCS length of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			ops = append(ops, diffOp{' ', x[i]})
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', x[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		ops = append(ops, diffOp{'-', x[i]})
+	}
+	for ; j < len(y); j++ {
+		ops = append(ops, diffOp{'+', y[j]})
+	}
+	return ops
+}
+
+// hasChanges reports whether ops contains any insertion or deletion
+func hasChanges(ops []diffOp) bool {
+	for _, op := range ops {
+		if op.Kind != ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// formatDiff renders ops as text, marking deletions as [-word-] and
+// insertions as {+word+} since dialogs can't show colours
+func formatDiff(ops []diffOp) string {
+	words := make([]string, len(ops))
+	for i, op := range ops {
+		switch op.Kind {
+		case '-':
+			words[i] = "[-" + op.Word + "-]"
+		case '+':
+			words[i] = "{+" + op.Word + "+}"
+		default:
+			words[i] = op.Word
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	backupsDirName = "backups"
+	// backupTimeFormat is RFC 3339 in UTC with the colons, which Windows
+	// doesn't allow in file names, replaced by dashes. Names sort by time.
+	backupTimeFormat = "2006-01-02T15-04-05.000Z"
+)
+
+// configBackup is a snapshot of settings.json in the backups folder
+type configBackup struct {
+	Name    string
+	Path    string
+	SavedAt time.Time
+}
+
+// backupsDir returns the folder config snapshots are kept in
+func backupsDir() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, backupsDirName), nil
+}
+
+// snapshotConfig copies the current settings.json to the backups folder
+// and deletes all but the newest maxBackups snapshots. Nothing is copied
+// before the first save.
+func snapshotConfig(dir string, maxBackups int) error {
+	data, err := os.ReadFile(filepath.Join(dir, configFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	backups := filepath.Join(dir, backupsDirName)
+	if err := os.MkdirAll(backups, 0o755); err != nil {
+		return fmt.Errorf("failed to create backups folder: %w", err)
+	}
+	name := "settings-" + time.Now().UTC().Format(backupTimeFormat) + ".json.bak"
+	if err := writeConfigFile(filepath.Join(backups, name), data); err != nil {
+		return fmt.Errorf("failed to back up config: %w", err)
+	}
+
+	existing, err := listBackups(backups)
+	if err != nil {
+		return err
+	}
+	for _, b := range existing[min(max(maxBackups, 1), len(existing)):] {
+		if err := os.Remove(b.Path); err != nil {
+			return fmt.Errorf("failed to delete old backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// listBackups returns the snapshots in dir, newest first
+func listBackups(dir string) ([]configBackup, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var backups []configBackup
+	for _, e := range entries {
+		stamp, ok := strings.CutPrefix(e.Name(), "settings-")
+		if !ok {
+			continue
+		}
+		stamp, ok = strings.CutSuffix(stamp, ".json.bak")
+		if !ok {
+			continue
+		}
+		savedAt, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, configBackup{Name: e.Name(), Path: filepath.Join(dir, e.Name()), SavedAt: savedAt})
+	}
+	slices.SortFunc(backups, func(a, b configBackup) int { return b.SavedAt.Compare(a.SavedAt) })
+	return backups, nil
+}
+
+// restoreBackup replaces settings.json with the named snapshot. It works
+// even when the current file doesn't load; that file is snapshotted first
+// so the restore can be undone, and put back if the snapshot doesn't load.
+func restoreBackup(name string) error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	if filepath.Base(name) != name {
+		return fmt.Errorf("%q is not a backup name", name)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, backupsDirName, name))
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	path := filepath.Join(dir, configFileName)
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	maxBackups := defaultConfig().MaxBackups
+	if cfg, err := loadConfig(); err == nil {
+		maxBackups = cfg.MaxBackups
+	}
+	if err := snapshotConfig(dir, maxBackups); err != nil {
+		return err
+	}
+	if err := writeConfigFile(path, data); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	if _, err := loadConfig(); err != nil {
+		if werr := writeConfigFile(path, current); werr != nil {
+			slog.Error(fmt.Sprintf("❌ Failed to put back %s: %v", configFileName, werr))
+		}
+		return fmt.Errorf("backup %s is not a valid config: %w", name, err)
+	}
+	return nil
+}
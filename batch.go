@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// batchFileDelimiter joins the paragraphs of a batch file when
+// Config.BatchDelimiter is empty
+const batchFileDelimiter = "\n\n"
+
+// translateBatchFile translates the paragraphs of a text file
+// independently, Config.BatchWorkers at a time, and writes
+// <name>_translated<ext> next to it with the same delimiter. Paragraphs are
+// split on blank lines, or on Config.BatchDelimiter when set. progress is
+// called after each paragraph; paragraphs that fail keep their original
+// text and are reported at the end.
+func (t *TranslatorApp) translateBatchFile(path string, prompt Prompt, progress func(done, total int)) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	text := strings.TrimSpace(decodeText(data))
+
+	cfg := t.currentConfig()
+	delimiter := cfg.BatchDelimiter
+	var paragraphs []string
+	if delimiter == "" {
+		delimiter = batchFileDelimiter
+		paragraphs = paragraphSeparator.Split(text, -1)
+	} else {
+		paragraphs = strings.Split(text, delimiter)
+	}
+
+	results := make([]string, len(paragraphs))
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var failed []int
+	done := 0
+
+	var wg sync.WaitGroup
+	for range max(cfg.BatchWorkers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result, err := paragraphs[i], error(nil)
+				if strings.TrimSpace(result) != "" {
+					result, err = t.translateChunk(context.Background(), prompt, paragraphs[i], "")
+				}
+
+				mu.Lock()
+				if err != nil {
+					slog.Warn(fmt.Sprintf("⚠️  Paragraph %d failed: %v", i+1, err))
+					failed = append(failed, i+1)
+					result = paragraphs[i]
+				}
+				results[i] = result
+				done++
+				progress(done, len(paragraphs))
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range paragraphs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	out := translatedPath(path)
+	if err := os.WriteFile(out, []byte(strings.Join(results, delimiter)+"\n"), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	if len(failed) > 0 {
+		slices.Sort(failed)
+		slog.Warn(fmt.Sprintf("⚠️  %d paragraphs kept their original text: %v", len(failed), failed))
+	}
+	return out, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Layouts for Config.BilingualFormat
+const (
+	bilingualBelow    = "below"
+	bilingualInline   = "inline"
+	bilingualFootnote = "footnote"
+)
+
+const defaultBilingualSeparator = "\n---\n"
+
+var superscriptDigits = []rune("⁰¹²³⁴⁵⁶⁷⁸⁹")
+
+// checkBilingualFormat rejects unknown bilingual layouts
+func checkBilingualFormat(cfg *Config) error {
+	switch cfg.BilingualFormat {
+	case "", bilingualBelow, bilingualInline, bilingualFootnote:
+		return nil
+	default:
+		return fmt.Errorf("bilingual_format must be %q, %q or %q, got %q",
+			bilingualBelow, bilingualInline, bilingualFootnote, cfg.BilingualFormat)
+	}
+}
+
+// bilingualText combines original and translated for pasting when
+// Config.BilingualMode is set, and returns translated otherwise
+func bilingualText(cfg *Config, original, translated string) string {
+	if !cfg.BilingualMode {
+		return translated
+	}
+	switch cfg.BilingualFormat {
+	case bilingualInline:
+		return interleaveSentences(original, translated)
+	case bilingualFootnote:
+		return footnoteTranslation(original, translated)
+	default:
+		return original + cfg.BilingualSeparator + translated
+	}
+}
+
+// sentencePairs splits both texts into sentences and pairs them up in
+// order. When the counts differ, the extra sentences are paired with "".
+func sentencePairs(original, translated string) [][2]string {
+	a, b := splitSentences(original), splitSentences(translated)
+	pairs := make([][2]string, max(len(a), len(b)))
+	for i := range pairs {
+		if i < len(a) {
+			pairs[i][0] = strings.TrimSpace(a[i])
+		}
+		if i < len(b) {
+			pairs[i][1] = strings.TrimSpace(b[i])
+		}
+	}
+	return pairs
+}
+
+// interleaveSentences puts each translated sentence on the line after its
+// original, with a blank line between pairs
+func interleaveSentences(original, translated string) string {
+	var blocks []string
+	for _, p := range sentencePairs(original, translated) {
+		blocks = append(blocks, strings.TrimSpace(p[0]+"\n"+p[1]))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// footnoteTranslation numbers each original sentence with a superscript
+// and lists the translations as footnotes below
+func footnoteTranslation(original, translated string) string {
+	var body, notes []string
+	for i, p := range sentencePairs(original, translated) {
+		mark := superscript(i + 1)
+		if p[0] != "" {
+			body = append(body, p[0]+mark)
+		}
+		if p[1] != "" {
+			notes = append(notes, mark+" "+p[1])
+		}
+	}
+	return strings.Join(body, " ") + "\n\n" + strings.Join(notes, "\n")
+}
+
+// superscript writes n with superscript digits
+func superscript(n int) string {
+	var b strings.Builder
+	for _, d := range strconv.Itoa(n) {
+		b.WriteRune(superscriptDigits[d-'0'])
+	}
+	return b.String()
+}
@@ -0,0 +1,73 @@
+//go:build !headless
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// budgetWarnShare is the share of Config.MonthlyBudgetUSD at which the
+// user is warned once per session
+const budgetWarnShare = 0.8
+
+// monthlySpend returns the estimated API spend of the current calendar
+// month
+func (t *TranslatorApp) monthlySpend() (float64, error) {
+	now := time.Now()
+	return t.history.SpendSince(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()))
+}
+
+// checkBudget reports whether a hotkey translation may go ahead. Past 80%
+// of Config.MonthlyBudgetUSD the user is warned once per session; past the
+// budget they must confirm to continue, which then holds for the session.
+func (t *TranslatorApp) checkBudget() bool {
+	budget := t.currentConfig().MonthlyBudgetUSD
+	if budget <= 0 {
+		return true
+	}
+	spend, err := t.monthlySpend()
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  %v", err))
+		return true
+	}
+	t.showSpend(spend)
+
+	t.mu.Lock()
+	warned, override := t.budgetWarned, t.budgetOverride
+	t.mu.Unlock()
+
+	switch {
+	case spend >= budget && !override:
+		msg := fmt.Sprintf("This month's estimated spend of $%.2f has reached the budget of $%.2f.", spend, budget)
+		slog.Error(fmt.Sprintf("❌ %s", msg))
+		if !showDialog("LingoSnap", msg+"\n\nTranslate anyway?", "Continue Anyway", "Stop", 0) {
+			return false
+		}
+		t.mu.Lock()
+		t.budgetOverride = true
+		t.mu.Unlock()
+	case spend >= budget*budgetWarnShare && !warned:
+		t.mu.Lock()
+		t.budgetWarned = true
+		t.mu.Unlock()
+		showWarning(fmt.Sprintf("This month's estimated spend of $%.2f is over %.0f%% of the budget of $%.2f", spend, budgetWarnShare*100, budget))
+	}
+	return true
+}
+
+// showSpend passes spend to the tray, if it is shown
+func (t *TranslatorApp) showSpend(spend float64) {
+	t.mu.Lock()
+	fn := t.onSpendChange
+	t.mu.Unlock()
+	if fn != nil {
+		fn(spend)
+	}
+}
+
+// spendTitle is the tray label for the month's spend
+func spendTitle(spend float64) string {
+	return fmt.Sprintf("This Month: $%.2f", spend)
+}
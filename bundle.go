@@ -0,0 +1,496 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	bundlePluginsDir = "plugins"
+
+	// encryptedKeyPrefix marks an API key encrypted with the bundle
+	// passphrase
+	encryptedKeyPrefix = "encrypted:"
+
+	// bundlePassphraseEnv holds the passphrase for -export-bundle and
+	// -import-bundle; it is asked for on the terminal when unset
+	bundlePassphraseEnv = "LINGOSNAP_BUNDLE_PASSPHRASE"
+
+	bundleSaltSize = 16
+)
+
+// errWrongPassphrase is returned when a bundle's API keys and tokens
+// can't be decrypted
+var errWrongPassphrase = errors.New("wrong passphrase")
+
+// bundleSecrets returns the secrets of cfg other than api_key and
+// api_keys, which exportBundle encrypts like them
+func bundleSecrets(cfg *Config) []*string {
+	return []*string{&cfg.DeepLKey, &cfg.VisionAPIKey, &cfg.ServerToken, &cfg.WebsocketToken}
+}
+
+// exportBundle writes cfg, the history and the plugins in Config.PluginDir
+// to a ZIP file at bundlePath, so the whole setup can be moved to another
+// machine. The API keys and tokens are encrypted with passphrase, or left
+// out when it is empty.
+func exportBundle(cfg *Config, history *HistoryStore, bundlePath, passphrase string) error {
+	bundled := *cfg
+	// deepl_key is bundled on its own, so api_key keeps its own value
+	withoutDeepL := *cfg
+	withoutDeepL.DeepLKey = ""
+	key, err := resolveAPIKey(&withoutDeepL)
+	if err != nil {
+		return err
+	}
+	keys, err := resolveAPIKeys(cfg)
+	if err != nil {
+		return err
+	}
+	if bundled.DeepLKey, err = resolveSecret(cfg.DeepLKey, accountDeepLKey); err != nil {
+		return err
+	}
+	if bundled.VisionAPIKey, err = resolveSecret(cfg.VisionAPIKey, accountVisionAPIKey); err != nil {
+		return err
+	}
+	bundled.APIKey, bundled.APIKeys = "", nil
+	for _, secret := range bundleSecrets(&bundled) {
+		plain := *secret
+		*secret = ""
+		if passphrase == "" {
+			continue
+		}
+		if *secret, err = encryptKey(plain, passphrase); err != nil {
+			return err
+		}
+	}
+	if passphrase != "" {
+		if bundled.APIKey, err = encryptKey(key, passphrase); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			encrypted, err := encryptKey(k, passphrase)
+			if err != nil {
+				return err
+			}
+			bundled.APIKeys = append(bundled.APIKeys, encrypted)
+		}
+	}
+	settings, err := json.MarshalIndent(&bundled, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	tmp, err := os.MkdirTemp("", "lingosnap-bundle-")
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	historyCopy := filepath.Join(tmp, historyFileName)
+	if err := history.SnapshotTo(historyCopy); err != nil {
+		return err
+	}
+
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	if err := addBundleFile(zw, configFileName, strings.NewReader(string(settings))); err != nil {
+		return err
+	}
+	if err := addBundleFileFrom(zw, historyFileName, historyCopy); err != nil {
+		return err
+	}
+	if cfg.PluginDir != "" {
+		plugins, err := filepath.Glob(filepath.Join(cfg.PluginDir, "*.so"))
+		if err != nil {
+			return fmt.Errorf("failed to list plugins: %w", err)
+		}
+		for _, p := range plugins {
+			if err := addBundleFileFrom(zw, path.Join(bundlePluginsDir, filepath.Base(p)), p); err != nil {
+				return err
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return f.Close()
+}
+
+func addBundleFile(zw *zip.Writer, name string, r io.Reader) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	return nil
+}
+
+func addBundleFileFrom(zw *zip.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	defer f.Close()
+	return addBundleFile(zw, name, f)
+}
+
+// importBundle applies a bundle written by exportBundle to cfg and
+// history. With replace the bundle's settings and history take the place
+// of the current ones; otherwise its prompts, glossary, template
+// variables and history entries are added to them, and its API keys are
+// used only when none are configured. With installPlugins, plugins are
+// copied to Config.PluginDir, or a plugins folder next to settings.json;
+// ask first, listing bundlePlugins, as they run with the user's
+// permissions on the next start.
+func importBundle(cfg *Config, history *HistoryStore, bundlePath, passphrase string, replace, installPlugins bool) error {
+	tmp, err := os.MkdirTemp("", "lingosnap-bundle-")
+	if err != nil {
+		return fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	if err := extractBundle(bundlePath, tmp); err != nil {
+		return err
+	}
+
+	imported, err := readBundleConfig(filepath.Join(tmp, configFileName), passphrase)
+	if err != nil {
+		return err
+	}
+	var plugins []string
+	if installPlugins {
+		if plugins, err = filepath.Glob(filepath.Join(tmp, bundlePluginsDir, "*.so")); err != nil {
+			return fmt.Errorf("failed to list plugins: %w", err)
+		}
+	}
+
+	next := mergeBundleConfig(cfg, imported, replace)
+	pluginDir := cfg.PluginDir
+	if len(plugins) > 0 && pluginDir == "" {
+		dir, err := configDir()
+		if err != nil {
+			return err
+		}
+		pluginDir = filepath.Join(dir, bundlePluginsDir)
+	}
+	if len(plugins) > 0 {
+		next.PluginDir = pluginDir
+	}
+	if err := saveConfig(next); err != nil {
+		return err
+	}
+	*cfg = *next
+	if err := migrateAPIKey(cfg); err != nil {
+		return err
+	}
+
+	if err := importBundleHistory(history, filepath.Join(tmp, historyFileName), replace); err != nil {
+		return err
+	}
+	if len(plugins) > 0 {
+		if err := os.MkdirAll(pluginDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create plugin folder: %w", err)
+		}
+		for _, p := range plugins {
+			if err := copyFile(p, filepath.Join(pluginDir, filepath.Base(p))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isBundlePlugin reports whether name is a plugin in a bundle
+func isBundlePlugin(name string) bool {
+	return path.Dir(name) == bundlePluginsDir && strings.HasSuffix(name, ".so")
+}
+
+// bundlePlugins returns the file names of the plugins in the bundle at
+// bundlePath
+func bundlePlugins(bundlePath string) ([]string, error) {
+	zr, err := zip.OpenReader(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		if isBundlePlugin(f.Name) {
+			names = append(names, path.Base(f.Name))
+		}
+	}
+	return names, nil
+}
+
+// extractBundle unpacks the files of the bundle at bundlePath that
+// LingoSnap knows into dir
+func extractBundle(bundlePath, dir string) error {
+	zr, err := zip.OpenReader(bundlePath)
+	if err != nil {
+		return fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer zr.Close()
+
+	if err := os.Mkdir(filepath.Join(dir, bundlePluginsDir), 0o755); err != nil {
+		return fmt.Errorf("failed to open bundle: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.Name != configFileName && f.Name != historyFileName && !isBundlePlugin(f.Name) {
+			continue
+		}
+		if err := extractBundleFile(f, filepath.Join(dir, filepath.FromSlash(f.Name))); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, configFileName)); err != nil {
+		return fmt.Errorf("%s has no %s, is it a LingoSnap bundle?", bundlePath, configFileName)
+	}
+	return nil
+}
+
+func extractBundleFile(f *zip.File, dest string) error {
+	r, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s from bundle: %w", f.Name, err)
+	}
+	defer r.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, r); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+	}
+	return out.Close()
+}
+
+// readBundleConfig reads the settings of a bundle, upgrading older ones,
+// and decrypts their API keys
+func readBundleConfig(path, passphrase string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle settings: %w", err)
+	}
+	migrated, version, err := migrateConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bundle settings: %w", err)
+	}
+	if version > configVersion {
+		return nil, fmt.Errorf("the bundle was written by a newer version of LingoSnap (v%d), update LingoSnap first", version)
+	}
+	cfg := defaultConfig()
+	if err := json.Unmarshal(migrated, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle settings: %w", err)
+	}
+
+	if cfg.APIKey, err = decryptKey(cfg.APIKey, passphrase); err != nil {
+		return nil, err
+	}
+	for _, secret := range bundleSecrets(cfg) {
+		if *secret, err = decryptKey(*secret, passphrase); err != nil {
+			return nil, err
+		}
+	}
+	for i, k := range cfg.APIKeys {
+		if cfg.APIKeys[i], err = decryptKey(k, passphrase); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// mergeBundleConfig returns the config to save after importing bundled
+func mergeBundleConfig(cfg, bundled *Config, replace bool) *Config {
+	if replace {
+		next := *bundled
+		// A bundle exported without a passphrase has no key to replace
+		// the current one with
+		if next.APIKey == "" && len(next.APIKeys) == 0 {
+			next.APIKey, next.APIKeys = cfg.APIKey, cfg.APIKeys
+		}
+		keepSecrets(&next, cfg)
+		next.KeyringBackend = cfg.KeyringBackend
+		next.PluginDir = cfg.PluginDir
+		return &next
+	}
+
+	next := *cfg
+	next.Prompts = mergePrompts(cfg.Prompts, bundled.Prompts, false)
+	next.Glossary = mergeGlossary(cfg.Glossary, bundled.Glossary)
+	next.TemplateVars = maps.Clone(cfg.TemplateVars)
+	for k, v := range bundled.TemplateVars {
+		if _, ok := next.TemplateVars[k]; !ok {
+			if next.TemplateVars == nil {
+				next.TemplateVars = make(map[string]string)
+			}
+			next.TemplateVars[k] = v
+		}
+	}
+	if cfg.APIKey == "" && len(cfg.APIKeys) == 0 && bundled.Provider == cfg.Provider {
+		next.APIKey, next.APIKeys = bundled.APIKey, bundled.APIKeys
+	}
+	keepSecrets(&next, bundled)
+	return &next
+}
+
+// keepSecrets fills the bundleSecrets that next lacks from other
+func keepSecrets(next, other *Config) {
+	from := bundleSecrets(other)
+	for i, secret := range bundleSecrets(next) {
+		if *secret == "" {
+			*secret = *from[i]
+		}
+	}
+}
+
+// importBundleHistory adds the entries of the bundled history database at
+// path, if the bundle has one
+func importBundleHistory(history *HistoryStore, path string, replace bool) error {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	bundled, err := openHistoryFile(path, 0)
+	if err != nil {
+		return err
+	}
+	defer bundled.Close()
+	entries, err := bundled.Search("", -1)
+	if err != nil {
+		return err
+	}
+	slices.Reverse(entries)
+	return history.Import(entries, replace)
+}
+
+// copyFile copies the file at src to dst, replacing dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %w", filepath.Base(src), err)
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %w", filepath.Base(src), err)
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, in); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", filepath.Base(src), err)
+	}
+	return out.Close()
+}
+
+// bundleCipher derives the AES-256-GCM cipher of a passphrase with scrypt
+func bundleCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptKey encrypts an API key with passphrase as "encrypted:" followed
+// by the base64 of the salt, the nonce and the sealed key
+func encryptKey(key, passphrase string) (string, error) {
+	if key == "" {
+		return "", nil
+	}
+	salt := make([]byte, bundleSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to encrypt API key: %w", err)
+	}
+	aead, err := bundleCipher(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to encrypt API key: %w", err)
+	}
+	sealed := aead.Seal(nil, nonce, []byte(key), nil)
+	return encryptedKeyPrefix + base64.StdEncoding.EncodeToString(slices.Concat(salt, nonce, sealed)), nil
+}
+
+// decryptKey reverses encryptKey. Values without the prefix are returned
+// as they are.
+func decryptKey(value, passphrase string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, encryptedKeyPrefix)
+	if !ok {
+		return value, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < bundleSaltSize {
+		return "", fmt.Errorf("invalid encrypted API key in bundle")
+	}
+	aead, err := bundleCipher(passphrase, data[:bundleSaltSize])
+	if err != nil {
+		return "", err
+	}
+	data = data[bundleSaltSize:]
+	if len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted API key in bundle")
+	}
+	key, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errWrongPassphrase
+	}
+	return string(key), nil
+}
+
+// stdinLines reads the answers to questions asked on the terminal
+var stdinLines = bufio.NewReader(os.Stdin)
+
+// readPassphrase returns the bundle passphrase from the environment or
+// asks for it on the terminal
+func readPassphrase() (string, error) {
+	if p := os.Getenv(bundlePassphraseEnv); p != "" {
+		return p, nil
+	}
+	fmt.Fprint(os.Stderr, "Bundle passphrase: ")
+	line, err := stdinLines.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// pluginWarning lists the plugins of a bundle before asking whether to
+// install them
+func pluginWarning(names []string) string {
+	return "The bundle contains plugins, which run with your permissions the next time LingoSnap starts:\n\n  " +
+		strings.Join(names, "\n  ") + "\n\nOnly install them if you trust where the bundle came from."
+}
+
+// confirmPluginsOnTerminal asks on the terminal whether to install the
+// plugins of a bundle. Anything but yes, including no answer, skips them.
+func confirmPluginsOnTerminal(names []string) bool {
+	fmt.Fprintf(os.Stderr, "%s\nInstall them? [y/N]: ", pluginWarning(names))
+	line, _ := stdinLines.ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// translationCache is an LRU cache of translations. Entries older than ttl
+// are treated as missing; a zero ttl keeps them until they are evicted.
+type translationCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // front is the most recently used
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key     string
+	value   string
+	created time.Time
+}
+
+func newTranslationCache(size int, ttl time.Duration) *translationCache {
+	return &translationCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// cacheKey identifies a translation of text by the settings it was made
+// with, rendered prompt and target language, so editing a prompt or its
+// language doesn't answer from translations made before
+func cacheKey(settings, prompt, targetLang, text string) string {
+	sum := sha256.Sum256([]byte(settings + "\x00" + prompt + "\x00" + targetLang + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheSettings returns the settings besides the prompt that change what
+// translateOnce returns for a text, so changing them doesn't answer from
+// the cache
+func cacheSettings(cfg *Config) string {
+	data, _ := json.Marshal([]any{
+		cfg.Provider, cfg.Model, cfg.Glossary, cfg.PIIMask, cfg.PIIPatterns,
+		cfg.PreProcessDefaults, cfg.PreProcessRules, cfg.PostProcessRules,
+		cfg.TransliterateArmenian, cfg.TransliterationTable, cfg.PreserveMarkdown, cfg.OutputFormat,
+	})
+	return string(data)
+}
+
+func (c *translationCache) get(key string) (string, bool) {
+	if c.size <= 0 {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := el.Value.(*cacheEntry)
+	if c.ttl > 0 && time.Since(entry.created) > c.ttl {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return "", false
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+func (c *translationCache) put(key, value string) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		el.Value = &cacheEntry{key: key, value: value, created: time.Now()}
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value, created: time.Now()})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTranslateOnceCaching(t *testing.T) {
+	fake := &fakeTranslator{}
+	app := newTestApp(t, fake)
+	app.cache = newTranslationCache(10, 0)
+	prompt := Prompt{Title: "Formal", Text: "Translate formally:", TargetLang: "de"}
+	translate := func(p Prompt) {
+		t.Helper()
+		if _, err := app.translateOnce(context.Background(), p, "hello"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	translate(prompt)
+	translate(prompt)
+	if fake.calls() != 1 {
+		t.Fatalf("repeated text reached the translator %d times, want once", fake.calls())
+	}
+
+	edited := prompt
+	edited.Text = "Translate casually:"
+	translate(edited)
+	if fake.calls() != 2 {
+		t.Errorf("edited prompt was answered from the cache")
+	}
+
+	otherLang := prompt
+	otherLang.TargetLang = "fr"
+	translate(otherLang)
+	if fake.calls() != 3 {
+		t.Errorf("other target language was answered from the cache")
+	}
+
+	// The title alone doesn't matter
+	renamed := prompt
+	renamed.Title = "Renamed"
+	translate(renamed)
+	if fake.calls() != 3 {
+		t.Errorf("renamed prompt missed the cache")
+	}
+
+	// Settings that change the result aren't answered from before either
+	calls := fake.calls()
+	for name, change := range map[string]func(cfg *Config){
+		"glossary":      func(cfg *Config) { cfg.Glossary = []GlossaryEntry{{Source: "hello", Target: "hallo"}} },
+		"PII masking":   func(cfg *Config) { cfg.PIIMask = !cfg.PIIMask },
+		"pre-process":   func(cfg *Config) { cfg.PreProcessDefaults = append(cfg.PreProcessDefaults, "spaces") },
+		"post-process":  func(cfg *Config) { cfg.PostProcessRules = []PostProcessRule{{Pattern: `x`, Replacement: "y"}} },
+		"transliterate": func(cfg *Config) { cfg.TransliterateArmenian = !cfg.TransliterateArmenian },
+		"markdown":      func(cfg *Config) { cfg.PreserveMarkdown = !cfg.PreserveMarkdown },
+	} {
+		app.updateConfig(func(cfg *Config) error { change(cfg); return nil })
+		translate(prompt)
+		if fake.calls() != calls+1 {
+			t.Errorf("changed %s was answered from the cache", name)
+		}
+		calls = fake.calls()
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// translateText runs text through prompt and then through each prompt
+// named by NextPromptTitle in turn, returning the final result
+func (t *TranslatorApp) translateText(ctx context.Context, prompt Prompt, text string) (string, error) {
+	steps, err := t.translateChain(ctx, prompt, text)
+	if err != nil {
+		return "", err
+	}
+	return steps[len(steps)-1], nil
+}
+
+// translateChain returns the output of every prompt in the chain starting
+// at prompt. The chain stops after Config.MaxChainDepth prompts, which
+// also ends prompts that name each other in a loop.
+func (t *TranslatorApp) translateChain(ctx context.Context, prompt Prompt, text string) ([]string, error) {
+	cfg := t.currentConfig()
+	if cfg.StructuredTranslate {
+		result, ok, err := t.translateStructured(ctx, prompt, text)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return []string{result}, nil
+		}
+	}
+
+	maxDepth := max(cfg.MaxChainDepth, 1)
+
+	var steps []string
+	for depth := 1; ; depth++ {
+		stepCtx := ctx
+		if prompt.NextPromptTitle != "" && depth < maxDepth {
+			// Only the last step is streamed, as only its output is the result
+			stepCtx = withoutChunkSink(ctx)
+		}
+		result, err := t.translateStep(stepCtx, prompt, text)
+		if err != nil {
+			if depth > 1 {
+				err = fmt.Errorf("chain step %d (%q): %w", depth, prompt.Title, err)
+			}
+			return nil, err
+		}
+		steps = append(steps, result)
+
+		if prompt.NextPromptTitle == "" {
+			return steps, nil
+		}
+		if depth >= maxDepth {
+			slog.Warn(fmt.Sprintf("⚠️  Prompt chain stopped after %d steps (max_chain_depth)", maxDepth))
+			return steps, nil
+		}
+		next, ok := t.findPrompt(prompt.NextPromptTitle)
+		if !ok {
+			slog.Warn(fmt.Sprintf("⚠️  Prompt %q chains to unknown prompt %q", prompt.Title, prompt.NextPromptTitle))
+			return steps, nil
+		}
+		log.Printf("   %q → %q", prompt.Title, next.Title)
+		prompt, text = next, result
+	}
+}
+
+// translateStep translates text with a single prompt. With
+// Config.ChunkSentences set, long texts are sent Config.ChunkSize
+// sentences at a time so no single request times out; each chunk is
+// cached, so translating the text again after a failure resumes where it
+// stopped.
+func (t *TranslatorApp) translateStep(ctx context.Context, prompt Prompt, text string) (string, error) {
+	cfg := t.currentConfig()
+	size := max(cfg.ChunkSize, 1)
+	sentences := splitSentences(text)
+	if !cfg.ChunkSentences || len(sentences) <= size {
+		return t.translateOnce(ctx, prompt, text)
+	}
+
+	// The chunks are joined afterwards, so their streams don't add up to
+	// the result
+	ctx = withoutChunkSink(ctx)
+	var result strings.Builder
+	for start := 0; start < len(sentences); start += size {
+		end := min(start+size, len(sentences))
+		chunk := strings.Join(sentences[start:end], "")
+		translated, err := t.translateOnce(ctx, prompt, strings.TrimSpace(chunk))
+		if err != nil {
+			return "", fmt.Errorf("sentences %d-%d of %d: %w", start+1, end, len(sentences), err)
+		}
+		// Keep the line breaks or spaces that followed the chunk
+		result.WriteString(translated + chunk[len(strings.TrimRight(chunk, " \t\r\n")):])
+		log.Printf("   Sentences %d/%d", end, len(sentences))
+	}
+	return strings.TrimSpace(result.String()), nil
+}
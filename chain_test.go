@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTranslateChain(t *testing.T) {
+	prompts := []Prompt{
+		{Title: "Single", Text: "single"},
+		{Title: "First", Text: "first", NextPromptTitle: "Second"},
+		{Title: "Second", Text: "second", NextPromptTitle: "Third"},
+		{Title: "Third", Text: "third"},
+		{Title: "Ping", Text: "ping", NextPromptTitle: "Pong"},
+		{Title: "Pong", Text: "pong", NextPromptTitle: "Ping"},
+		{Title: "Self", Text: "self", NextPromptTitle: "Self"},
+		{Title: "Dangling", Text: "dangling", NextPromptTitle: "Missing"},
+	}
+	tests := []struct {
+		name      string
+		start     string
+		maxDepth  int
+		wantSteps int
+	}{
+		{"no chain", "Single", 5, 1},
+		{"chain shorter than the limit", "First", 5, 3},
+		{"chain cut at the limit", "First", 2, 2},
+		{"loop ends at the limit", "Ping", 5, 5},
+		{"self loop ends at the limit", "Self", 4, 4},
+		{"zero depth still translates once", "Ping", 0, 1},
+		{"unknown next prompt", "Dangling", 5, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeTranslator{}
+			app := newTestApp(t, fake)
+			app.config.Prompts = prompts
+			app.config.MaxChainDepth = tt.maxDepth
+			start, ok := app.findPrompt(tt.start)
+			if !ok {
+				t.Fatalf("no prompt %q", tt.start)
+			}
+
+			steps, err := app.translateChain(context.Background(), start, "hi")
+			if err != nil {
+				t.Fatalf("translateChain() failed: %v", err)
+			}
+			if len(steps) != tt.wantSteps {
+				t.Fatalf("got %d steps, want %d: %q", len(steps), tt.wantSteps, steps)
+			}
+			if fake.calls() != tt.wantSteps {
+				t.Errorf("translator called %d times, want %d", fake.calls(), tt.wantSteps)
+			}
+			// Each step translates the output of the one before
+			for i, step := range steps {
+				want := strings.Repeat("translated: ", i+1) + "hi"
+				if step != want {
+					t.Errorf("step %d = %q, want %q", i+1, step, want)
+				}
+			}
+		})
+	}
+}
+
+// streamingFake streams the reply of fakeTranslator a word at a time
+type streamingFake struct {
+	fakeTranslator
+}
+
+func (f *streamingFake) TranslateStream(ctx context.Context, prompt, text string, chunks chan<- string) (string, error) {
+	defer close(chunks)
+	reply, _ := f.Translate(ctx, prompt, text)
+	for _, word := range strings.SplitAfter(reply, " ") {
+		chunks <- word
+	}
+	return reply, nil
+}
+
+func TestStreamingSendsOnlyTheResult(t *testing.T) {
+	prompts := []Prompt{
+		{Title: "First", Text: "first", NextPromptTitle: "Second"},
+		{Title: "Second", Text: "second"},
+	}
+	tests := []struct {
+		name       string
+		start      string
+		glossary   []GlossaryEntry
+		wantChunks bool
+	}{
+		{"single prompt", "Second", nil, true},
+		{"last step of a chain", "First", nil, true},
+		{"glossary placeholders", "Second", []GlossaryEntry{{Source: "cat", Target: "Katze"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t, &streamingFake{})
+			app.config.Prompts = prompts
+			app.config.Glossary = tt.glossary
+			start, _ := app.findPrompt(tt.start)
+
+			var streamed strings.Builder
+			ctx := withChunkSink(context.Background(), func(chunk string) { streamed.WriteString(chunk) })
+			result, err := app.translateText(ctx, start, "the cat")
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case tt.wantChunks && streamed.String() != result:
+				t.Errorf("streamed %q, want the result %q", streamed.String(), result)
+			case !tt.wantChunks && streamed.Len() > 0:
+				t.Errorf("streamed %q, want no chunks for result %q", streamed.String(), result)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runCLI translates text (or stdin when text is empty) with the prompt
+// titled promptTitle, or the selected prompt, and prints the result
+func (t *TranslatorApp) runCLI(text, promptTitle string) error {
+	if text == "" {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		text = string(input)
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("no text to translate")
+	}
+
+	prompt := t.selectedPrompt()
+	if promptTitle != "" {
+		var ok bool
+		if prompt, ok = t.findPrompt(promptTitle); !ok {
+			return fmt.Errorf("no prompt titled %q", promptTitle)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), translationTimeout(t.currentConfig(), prompt))
+	defer cancel()
+
+	translated, err := t.translateText(ctx, prompt, text)
+	if err != nil {
+		return err
+	}
+	fmt.Println(translated)
+	return nil
+}
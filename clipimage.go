@@ -0,0 +1,41 @@
+//go:build !headless
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"log/slog"
+	"sync"
+
+	imgclipboard "golang.design/x/clipboard"
+)
+
+var (
+	imageClipboardOnce sync.Once
+	imageClipboardErr  error
+)
+
+// readClipboardImage returns the image on the clipboard, if any. The
+// text-only clipboard package can't see images, so this goes through
+// golang.design/x/clipboard, initialised on first use.
+func readClipboardImage() (image.Image, bool) {
+	imageClipboardOnce.Do(func() { imageClipboardErr = imgclipboard.Init() })
+	if imageClipboardErr != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Image clipboard unavailable: %v", imageClipboardErr))
+		return nil, false
+	}
+
+	data := imgclipboard.Read(imgclipboard.FmtImage)
+	if len(data) == 0 {
+		return nil, false
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to decode clipboard image: %v", err))
+		return nil, false
+	}
+	return img, true
+}
@@ -0,0 +1,90 @@
+//go:build !headless
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+)
+
+// watchClipboard polls the clipboard and translates new text in place
+// while Config.WatchClipboard is on. Changes made while a translation is
+// running come from LingoSnap itself and are skipped, which also keeps the
+// watcher from translating its own output.
+func (t *TranslatorApp) watchClipboard() {
+	interval := time.Duration(max(t.currentConfig().ClipboardPollMs, 50)) * time.Millisecond
+	last, _ := clipboard.ReadAll()
+	skipNext := false
+
+	for range time.Tick(interval) {
+		current, err := clipboard.ReadAll()
+		if err != nil {
+			continue
+		}
+		if t.queue.pending() > 0 {
+			skipNext = true
+			continue
+		}
+		if skipNext || !t.watchingClipboard() || !t.enabled() {
+			last, skipNext = current, false
+			continue
+		}
+		if current == last || strings.TrimSpace(current) == "" {
+			continue
+		}
+		// The job may finish before the next tick, so skip it explicitly
+		last, skipNext = current, true
+
+		prompt := t.selectedPrompt()
+		log.Printf("▶ Clipboard changed - translating with %q...", prompt.Title)
+		t.queue.enqueue("clipboard", func(ctx context.Context) { t.translateClipboard(ctx, prompt, current) })
+	}
+}
+
+// translateClipboard replaces text on the clipboard with its translation,
+// unless the clipboard changed again in the meantime
+func (t *TranslatorApp) translateClipboard(ctx context.Context, prompt Prompt, text string) {
+	_, limiter := t.backend()
+	if err := limiter.waitForRoom(ctx, estimateTokens(prompt.Text+text)); err != nil {
+		slog.Error(fmt.Sprintf("❌ %v", err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, translationTimeout(t.currentConfig(), prompt))
+	defer cancel()
+
+	translated, err := t.translateText(ctx, prompt, text)
+	if err != nil {
+		slog.Error(fmt.Sprintf("❌ %v", err))
+		return
+	}
+	if current, err := clipboard.ReadAll(); err != nil || current != text {
+		log.Println("   Clipboard changed during translation, discarding the result")
+		return
+	}
+	if err := clipboard.WriteAll(translated); err != nil {
+		slog.Error(fmt.Sprintf("❌ Failed to write to clipboard: %v", err))
+		return
+	}
+	log.Printf("✅ Clipboard translated: %s", truncateText(translated, 50))
+}
+
+func (t *TranslatorApp) watchingClipboard() bool {
+	return t.currentConfig().WatchClipboard
+}
+
+// setWatchClipboard turns the clipboard watcher on or off and saves it
+func (t *TranslatorApp) setWatchClipboard(on bool) {
+	err := t.updateConfig(func(cfg *Config) error {
+		cfg.WatchClipboard = on
+		return saveConfig(cfg)
+	})
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to save config: %v", err))
+	}
+}
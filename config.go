@@ -0,0 +1,392 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	configDirName  = "lingosnap"
+	configFileName = "settings.json"
+)
+
+// Config holds the user settings persisted in settings.json
+type Config struct {
+	Version       int  `json:"version"`
+	Enabled       bool `json:"enabled"`
+	StartAtLogin  bool `json:"start_at_login"`
+	BackupEnabled bool `json:"backup_enabled"`
+	MaxBackups    int  `json:"max_backups"`
+
+	Provider         string            `json:"provider"`
+	BaseURL          string            `json:"base_url"`
+	CustomEndpoint   string            `json:"custom_endpoint"`
+	PluginDir        string            `json:"plugin_dir"`
+	APIKey           string            `json:"api_key,omitempty"`
+	APIKeys          []string          `json:"api_keys,omitempty"`
+	DeepLKey         string            `json:"deepl_key,omitempty"`
+	AutoValidateKey  bool              `json:"auto_validate_key"`
+	Model            string            `json:"model"`
+	AvailableModels  []string          `json:"available_models,omitempty"`
+	ModelsCachedAt   *time.Time        `json:"models_cached_at,omitempty"`
+	Hotkey           string            `json:"hotkey"`
+	CopyHotkey       string            `json:"copy_hotkey"`
+	UndoHotkey       string            `json:"undo_hotkey"`
+	PauseHotkey      string            `json:"pause_hotkey"`
+	SettingsHotkey   string            `json:"settings_hotkey"`
+	HotkeyCooldownMs int               `json:"hotkey_cooldown_ms"`
+	UseSystemCopy    bool              `json:"use_system_copy"`
+	ContextLines     int               `json:"context_lines"`
+	AllowedMonitors  []int             `json:"allowed_monitors"`
+	Prompts          []Prompt          `json:"prompts"`
+	PromptHistory    []PromptVersion   `json:"prompt_history,omitempty"`
+	TemplateVars     map[string]string `json:"template_vars"`
+	SelectedIndex    int               `json:"selected_index"`
+	AutoSelectPrompt bool              `json:"auto_select_prompt"`
+	MaxChainDepth    int               `json:"max_chain_depth"`
+	MaxHistory       int               `json:"max_history"`
+	AnkiMaxLen       int               `json:"anki_max_len"`
+	Streaming        bool              `json:"streaming"`
+
+	Temperature    float32           `json:"temperature"`
+	TopP           float32           `json:"top_p"`
+	SafetySettings map[string]string `json:"safety_settings"`
+
+	OutputFormat   string `json:"output_format"`
+	OutputJSONPath string `json:"output_json_path"`
+
+	BilingualMode      bool   `json:"bilingual_mode"`
+	BilingualSeparator string `json:"bilingual_separator"`
+	BilingualFormat    string `json:"bilingual_format"`
+
+	AnnotateMode          bool   `json:"annotate_mode"`
+	AnnotateOpen          string `json:"annotate_open"`
+	AnnotateClose         string `json:"annotate_close"`
+	AnnotateCleanupHotkey string `json:"annotate_cleanup_hotkey"`
+
+	StructuredTranslate      bool     `json:"structured_translate"`
+	PreserveMarkdown         bool     `json:"preserve_markdown"`
+	ConfirmBeforePaste       bool     `json:"confirm_before_paste"`
+	CompareMode              bool     `json:"compare_mode"`
+	DryRun                   bool     `json:"dry_run"`
+	ShowAlternatives         bool     `json:"show_alternatives"`
+	BackTranslate            bool     `json:"back_translate"`
+	BackTranslateBlockOnDiff bool     `json:"back_translate_block_on_diff"`
+	SkipSameLanguage         bool     `json:"skip_same_language"`
+	WatchClipboard           bool     `json:"watch_clipboard"`
+	ClipboardAfterPaste      string   `json:"clipboard_after_paste"`
+	ClipboardPollMs          int      `json:"clipboard_poll_ms"`
+	PopupTimeout             Duration `json:"popup_timeout"`
+	MaxWindows               int      `json:"max_windows"`
+	Theme                    string   `json:"theme"`
+	KeyboardNav              bool     `json:"keyboard_nav"`
+	Notifications            bool     `json:"notifications"`
+	DialogWidth              int      `json:"dialog_width"`
+	DialogHeight             int      `json:"dialog_height"`
+	UIScale                  float32  `json:"ui_scale"`
+
+	TimeoutSecs      int      `json:"timeout_secs"`
+	MaxRetries       int      `json:"max_retries"`
+	RetryBackoffBase Duration `json:"retry_backoff_base"`
+	ThrottleCooldown Duration `json:"throttle_cooldown"`
+
+	// ShutdownTimeoutSecs is how long quitting waits for running
+	// translations
+	ShutdownTimeoutSecs int `json:"shutdown_timeout_secs"`
+
+	MaxRequestsPerMin int     `json:"max_requests_per_min"`
+	MaxTokensPerMin   int     `json:"max_tokens_per_min"`
+	MonthlyBudgetUSD  float64 `json:"monthly_budget_usd"`
+
+	HTTPProxy string `json:"http_proxy"`
+	NoProxy   string `json:"no_proxy"`
+
+	FeedbackURL  string `json:"feedback_url"`
+	LogLevel     string `json:"log_level"`
+	LogFile      string `json:"log_file"`
+	ServerToken  string `json:"server_token"`
+	OTLPEndpoint string `json:"otlp_endpoint"`
+	MetricsAddr  string `json:"metrics_addr"`
+
+	WebsocketAddr  string `json:"websocket_addr"`
+	WebsocketToken string `json:"websocket_token"`
+
+	QueueSize int      `json:"queue_size"`
+	JobTTL    Duration `json:"job_ttl"`
+
+	KeyringBackend string `json:"keyring_backend"`
+
+	SRTBatchSize   int    `json:"srt_batch_size"`
+	MaxChunkChars  int    `json:"max_chunk_chars"`
+	BatchDelimiter string `json:"batch_delimiter"`
+	BatchWorkers   int    `json:"batch_workers"`
+	ChunkSentences bool   `json:"chunk_sentences"`
+	ChunkSize      int    `json:"chunk_size"`
+
+	CacheSize       int      `json:"cache_size"`
+	CacheTTL        Duration `json:"cache_ttl"`
+	DedupeThreshold float64  `json:"dedupe_threshold"`
+
+	OCREnabled    bool   `json:"ocr_enabled"`
+	OCRHotkey     string `json:"ocr_hotkey"`
+	OCRProvider   string `json:"ocr_provider"`
+	TesseractPath string `json:"tesseract_path"`
+	TesseractLang string `json:"tesseract_lang"`
+	VisionAPIKey  string `json:"vision_api_key"`
+
+	TransliterateArmenian bool              `json:"transliterate_armenian"`
+	TransliterationTable  map[string]string `json:"transliteration_table"`
+
+	Glossary    []GlossaryEntry `json:"glossary"`
+	PIIMask     bool            `json:"pii_mask"`
+	PIIPatterns []string        `json:"pii_patterns"`
+	AppProfiles []AppProfile    `json:"app_profiles"`
+
+	PostProcessRules   []PostProcessRule `json:"post_process_rules"`
+	PreProcessRules    []PreProcessRule  `json:"pre_process_rules"`
+	PreProcessDefaults []string          `json:"pre_process_defaults"`
+}
+
+// clone returns a deep copy of c. Every field round-trips through
+// settings.json, so the copy shares no slices or maps with c.
+func (c *Config) clone() (*Config, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	next := &Config{}
+	if err := json.Unmarshal(data, next); err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	return next, nil
+}
+
+// Duration is a time.Duration stored as a string such as "500ms"
+type Duration time.Duration
+
+// Std returns d as a time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"500ms\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Version:    configVersion,
+		MaxBackups: 5,
+		LogLevel:   "info",
+		Enabled:    true,
+		Provider:   providerGemini,
+		Model:      "gemini-2.0-flash",
+		Hotkey:     defaultHotkey,
+		UndoHotkey: "ctrl+alt+z",
+
+		HotkeyCooldownMs: 300,
+		SettingsHotkey:   defaultSettingsHotkey,
+		MaxHistory:       500,
+		AnkiMaxLen:       500,
+
+		MaxChainDepth: 5,
+
+		Temperature: 1.0,
+		TopP:        0.95,
+
+		OutputFormat: outputPlain,
+
+		BilingualSeparator: defaultBilingualSeparator,
+		BilingualFormat:    bilingualBelow,
+
+		AnnotateOpen:  defaultAnnotateOpen,
+		AnnotateClose: defaultAnnotateClose,
+
+		ClipboardPollMs: 500,
+		Theme:           themeSystem,
+		UIScale:         1,
+		Notifications:   true,
+		MaxWindows:      3,
+
+		ClipboardAfterPaste: afterPasteRestore,
+
+		TimeoutSecs:      20,
+		MaxRetries:       3,
+		RetryBackoffBase: Duration(500 * time.Millisecond),
+		ThrottleCooldown: Duration(time.Minute),
+
+		ShutdownTimeoutSecs: 10,
+
+		MaxRequestsPerMin: 10,
+		MaxTokensPerMin:   100000,
+
+		SRTBatchSize:  10,
+		MaxChunkChars: 4000,
+		BatchWorkers:  3,
+		ChunkSize:     5,
+
+		QueueSize: 5,
+		JobTTL:    Duration(30 * time.Second),
+
+		OCRProvider: ocrTesseract,
+
+		CacheSize: 100,
+		CacheTTL:  Duration(time.Hour),
+
+		DedupeThreshold: 0.95,
+	}
+}
+
+// configDir returns the per-user directory lingosnap keeps its files in,
+// creating it if needed
+func configDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+
+	dir := filepath.Join(base, configDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return dir, nil
+}
+
+// loadConfig reads settings.json, falling back to defaults for missing fields.
+// A default file is written on first launch so users have something to edit.
+func loadConfig() (*Config, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := defaultConfig()
+	data, err := os.ReadFile(filepath.Join(dir, configFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, saveConfig(cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	migrated, version, err := migrateConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", configFileName, err)
+	}
+	if version > configVersion {
+		slog.Warn(fmt.Sprintf("⚠️  %s was written by a newer version of LingoSnap (v%d), some settings may be ignored", configFileName, version))
+	}
+
+	if err := json.Unmarshal(migrated, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", configFileName, err)
+	}
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+	if version < configVersion {
+		if err := backupConfig(dir, data, version); err != nil {
+			return nil, err
+		}
+		if err := saveConfig(cfg); err != nil {
+			return nil, err
+		}
+		log.Printf("✅ Migrated %s from version %d to %d", configFileName, version, configVersion)
+	}
+	if err := migrateAPIKey(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validateConfig runs the checks a config has to pass before it is used
+// or saved
+func validateConfig(cfg *Config) error {
+	for _, check := range []func(*Config) error{
+		checkHotkeyConflicts,
+		checkPromptTemplates,
+		checkGenerationSettings,
+		checkTimeouts,
+		checkBilingualFormat,
+		checkTheme,
+		checkCustomEndpoint,
+		checkPIIPatterns,
+		checkPostProcessRules,
+		checkPreProcessRules,
+		checkLogLevel,
+		checkClipboardAfterPaste,
+	} {
+		if err := check(cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveConfig writes the config back to settings.json
+func saveConfig(cfg *Config) error {
+	if err := validateConfig(cfg); err != nil {
+		return err
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	recordPromptVersions(cfg, savedPrompts(dir), time.Now())
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	if cfg.BackupEnabled {
+		if err := snapshotConfig(dir, cfg.MaxBackups); err != nil {
+			slog.Warn(fmt.Sprintf("⚠️  %v", err))
+		}
+	}
+	if err := writeConfigFile(filepath.Join(dir, configFileName), data); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// writeConfigFile writes settings.json or a copy of it, readable only by
+// the user since it can hold API keys. The data goes to a temporary file
+// that replaces path, so a crash never leaves half a config behind.
+func writeConfigFile(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
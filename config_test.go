@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSaveConfigIsPrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows doesn't use Unix permissions")
+	}
+	useTempConfigDir(t)
+	cfg := defaultConfig()
+	cfg.KeyringBackend = keyringNone
+	cfg.BackupEnabled = true
+	cfg.APIKey = "plain"
+	if err := saveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.APIKey = "changed"
+	if err := saveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, backupsDirName, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("backups = %q, want one snapshot", files)
+	}
+	for _, path := range append(files, filepath.Join(dir, configFileName)) {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("%s has mode %o, want 600", filepath.Base(path), perm)
+		}
+	}
+
+	saved, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.APIKey != "changed" {
+		t.Errorf("api_key = %q, want %q", saved.APIKey, "changed")
+	}
+}
+
+func TestUpdateConfigKeepsTheRunningConfig(t *testing.T) {
+	app := newTestApp(t, &fakeTranslator{})
+	app.config.Prompts = []Prompt{{Title: "Formal", Text: "Translate formally:"}}
+	app.config.Glossary = []GlossaryEntry{{Source: "cat", Target: "Katze"}}
+	running := app.currentConfig()
+
+	err := app.updateConfig(func(cfg *Config) error {
+		cfg.Prompts[0].Text = "Translate casually:"
+		cfg.Glossary[0].Target = "Kater"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if running.Prompts[0].Text != "Translate formally:" || running.Glossary[0].Target != "Katze" {
+		t.Errorf("the running config changed: %+v, %+v", running.Prompts, running.Glossary)
+	}
+	if got := app.currentConfig(); got.Prompts[0].Text != "Translate casually:" || got.Glossary[0].Target != "Kater" {
+		t.Errorf("the change wasn't applied: %+v, %+v", got.Prompts, got.Glossary)
+	}
+
+	updated := app.currentConfig()
+	if err := app.updateConfig(func(cfg *Config) error {
+		cfg.Prompts = nil
+		return errors.New("failed")
+	}); err == nil {
+		t.Fatal("updateConfig() hid the error")
+	}
+	if app.currentConfig() != updated {
+		t.Error("a failed change was swapped in")
+	}
+}
+
+func TestUpdateConfigWhileTranslating(t *testing.T) {
+	app := newTestApp(t, &fakeTranslator{})
+	app.config.Glossary = []GlossaryEntry{{Source: "cat", Target: "Katze"}}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 50 {
+			if _, err := app.translateText(context.Background(), defaultPrompt, "the cat"); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := range 50 {
+		app.updateConfig(func(cfg *Config) error {
+			cfg.Glossary[0].Target = fmt.Sprint("Katze ", i)
+			cfg.PreProcessDefaults = append(cfg.PreProcessDefaults, "spaces")
+			return nil
+		})
+	}
+	<-done
+}
+
+func TestUpdateConfigResetsTheClient(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+	fake := &fakeTranslator{}
+	app := newTestApp(t, fake)
+	update := func(change func(cfg *Config)) {
+		t.Helper()
+		if err := app.updateConfig(func(cfg *Config) error { change(cfg); return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	update(func(cfg *Config) { cfg.Temperature = 0.5 })
+	if translator, _ := app.backend(); translator != fake {
+		t.Fatal("a generation setting rebuilt the translator")
+	}
+
+	update(func(cfg *Config) {
+		cfg.Provider, cfg.Model, cfg.APIKey = providerGemini, "gemini-2.0-flash", "new-key"
+		cfg.MaxRequestsPerMin = 10
+	})
+	translator, limiter := app.backend()
+	gemini, ok := translator.(*GeminiTranslator)
+	if !ok {
+		t.Fatalf("translator is %T after a new key, want *GeminiTranslator", translator)
+	}
+	if limiter == nil || limiter.maxRequests != 10 {
+		t.Fatalf("limiter = %+v, want 10 requests per minute", limiter)
+	}
+
+	// Other settings apply to the same translator and limiter
+	update(func(cfg *Config) { cfg.Temperature = 0.9 })
+	if translator, l := app.backend(); translator != gemini || l != limiter {
+		t.Error("a generation setting rebuilt the translator or limiter")
+	}
+	if got := gemini.config().Temperature; got != 0.9 {
+		t.Errorf("translator reads temperature %g, want 0.9", got)
+	}
+
+	update(func(cfg *Config) { cfg.MaxRequestsPerMin, cfg.MaxTokensPerMin = 0, 0 })
+	if _, l := app.backend(); l != nil {
+		t.Errorf("limiter = %+v without limits, want nil", l)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// csvTranslatedSuffix is appended to the name of a column for the column
+// holding its translation
+const csvTranslatedSuffix = "_translated"
+
+// csvLanguageInstruction names the target language of a column, which the
+// prompt may not refer to or may hard-code
+const csvLanguageInstruction = "\n\nTranslate the text into the language with the ISO 639-1 code %q, whatever language the instructions above name. Return only the translation."
+
+// csvColumn is a column of a CSV file to translate into lang, or into the
+// target language of the prompt when lang is empty
+type csvColumn struct {
+	name, lang string
+}
+
+// parseCSVColumns parses a comma-separated list of column names, each
+// optionally followed by a colon and its target language, as in
+// "name:de, description"
+func parseCSVColumns(spec string) ([]csvColumn, error) {
+	var columns []csvColumn
+	for _, part := range strings.Split(spec, ",") {
+		name, lang, _ := strings.Cut(part, ":")
+		name, lang = strings.TrimSpace(name), strings.TrimSpace(lang)
+		if name == "" {
+			continue
+		}
+		if lang != "" && !isoCodePattern.MatchString(strings.ToLower(lang)) {
+			return nil, fmt.Errorf("column %q has target language %q, use a code such as de", name, lang)
+		}
+		columns = append(columns, csvColumn{name: name, lang: lang})
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("no columns to translate")
+	}
+	return columns, nil
+}
+
+// readCSV reads all records of a CSV file, the header first. Rows may have
+// fewer or more cells than the header.
+func readCSV(path string) ([][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	r := csv.NewReader(strings.NewReader(decodeText(data)))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	return records, nil
+}
+
+// readCSVHeader returns the column names of a CSV file
+func readCSVHeader(path string) ([]string, error) {
+	records, err := readCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	return records[0], nil
+}
+
+// translateCSVFile translates the cells of columns with prompt,
+// Config.BatchWorkers at a time, and writes <name>_translated.csv next to
+// the file. The translations go in new columns named
+// <column>_translated after the existing ones, which are copied unchanged.
+// progress is called after each cell; cells that fail get their original
+// text and are reported at the end.
+func (t *TranslatorApp) translateCSVFile(path string, prompt Prompt, columns []csvColumn, progress func(done, total int)) (string, error) {
+	records, err := readCSV(path)
+	if err != nil {
+		return "", err
+	}
+	header := records[0]
+	indexes := make([]int, len(columns))
+	for i, c := range columns {
+		indexes[i] = slices.Index(header, c.name)
+		if indexes[i] < 0 {
+			return "", fmt.Errorf("%s has no column %q", path, c.name)
+		}
+		if slices.Contains(header, c.name+csvTranslatedSuffix) {
+			return "", fmt.Errorf("%s already has a column %q", path, c.name+csvTranslatedSuffix)
+		}
+	}
+
+	// The output rows are padded to the header, then end in a cell per
+	// column
+	rows := make([][]string, len(records))
+	rows[0] = slices.Clone(header)
+	for _, c := range columns {
+		rows[0] = append(rows[0], c.name+csvTranslatedSuffix)
+	}
+	type cell struct{ row, column int }
+	var cells []cell
+	for r, record := range records[1:] {
+		row := slices.Clone(record)
+		for len(row) < len(header) {
+			row = append(row, "")
+		}
+		rows[r+1] = append(row, make([]string, len(columns))...)
+		for i := range columns {
+			if strings.TrimSpace(row[indexes[i]]) != "" {
+				cells = append(cells, cell{r + 1, i})
+			}
+		}
+	}
+
+	// Columns with their own language get an instruction naming it; it
+	// also becomes the TargetLang that backends such as DeepL are given
+	prompts := make([]Prompt, len(columns))
+	instructions := make([]string, len(columns))
+	for i, c := range columns {
+		prompts[i] = prompt
+		if c.lang != "" {
+			prompts[i].TargetLang = c.lang
+			instructions[i] = fmt.Sprintf(csvLanguageInstruction, c.lang)
+		}
+	}
+
+	jobs := make(chan cell)
+	var mu sync.Mutex
+	var failed []cell
+	done := 0
+
+	var wg sync.WaitGroup
+	for range max(t.currentConfig().BatchWorkers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				original := rows[c.row][indexes[c.column]]
+				result, err := t.translateChunk(context.Background(), prompts[c.column], original, instructions[c.column])
+
+				mu.Lock()
+				if err != nil {
+					slog.Warn(fmt.Sprintf("⚠️  Row %d, column %q failed: %v", c.row+1, columns[c.column].name, err))
+					failed = append(failed, c)
+					result = original
+				}
+				row := rows[c.row]
+				row[len(row)-len(columns)+c.column] = result
+				done++
+				progress(done, len(cells))
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, c := range cells {
+		jobs <- c
+	}
+	close(jobs)
+	wg.Wait()
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return "", fmt.Errorf("failed to encode CSV: %w", err)
+	}
+	out := translatedPath(path)
+	if err := os.WriteFile(out, buf.Bytes(), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	if len(failed) > 0 {
+		slices.SortFunc(failed, func(a, b cell) int { return cmp.Or(a.row-b.row, a.column-b.column) })
+		names := make([]string, len(failed))
+		for i, c := range failed {
+			names[i] = fmt.Sprintf("row %d %s", c.row+1, columns[c.column].name)
+		}
+		slog.Warn(fmt.Sprintf("⚠️  %d cells kept their original text: %s", len(failed), strings.Join(names, ", ")))
+	}
+	return out, nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	deeplFreeURL = "https://api-free.deepl.com/v2"
+	deeplProURL  = "https://api.deepl.com/v2"
+)
+
+// deeplQuotaExceeded is the HTTP status DeepL answers with once the
+// character quota of the billing period is used up
+const deeplQuotaExceeded = 456
+
+// errDeepLQuota is returned when the DeepL character quota is used up
+var errDeepLQuota = errors.New("the DeepL character quota for this billing period is used up; it resets with the next period, or upgrade the plan")
+
+// LanguageDetector is implemented by backends that tell the language of a
+// text themselves, rather than through detectLanguagePrompt
+type LanguageDetector interface {
+	DetectLanguage(ctx context.Context, text string) (string, error)
+}
+
+// languagesKey carries the languages of the translation under a context
+type languagesKey struct{}
+
+// translationLanguages are the languages of a translation, for backends
+// that take them as parameters rather than in the prompt. detected is
+// set by backends that report the language they found.
+type translationLanguages struct {
+	source, target string
+	detected       string
+}
+
+// withLanguages passes the source and target languages of the prompt to
+// the backend under ctx. source may be empty.
+func withLanguages(ctx context.Context, source, target string) (context.Context, *translationLanguages) {
+	langs := &translationLanguages{source: source, target: target}
+	return context.WithValue(ctx, languagesKey{}, langs), langs
+}
+
+// languagesFrom returns the languages set by withLanguages
+func languagesFrom(ctx context.Context) (*translationLanguages, bool) {
+	langs, ok := ctx.Value(languagesKey{}).(*translationLanguages)
+	return langs, ok
+}
+
+// DeepLTranslator translates through the DeepL API. DeepL takes no
+// instructions, so the prompt text is ignored and only the languages of
+// the prompt are used.
+type DeepLTranslator struct {
+	baseURL   string
+	modelType string
+	apiKey    string
+	client    *http.Client
+}
+
+func newDeepLTranslator(baseURL, modelType, apiKey string, client *http.Client) *DeepLTranslator {
+	if baseURL == "" {
+		// Keys of the free plan end in ":fx" and only work on its endpoint
+		baseURL = deeplProURL
+		if strings.HasSuffix(apiKey, ":fx") {
+			baseURL = deeplFreeURL
+		}
+	}
+	return &DeepLTranslator{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		modelType: modelType,
+		apiKey:    apiKey,
+		client:    client,
+	}
+}
+
+type deeplRequest struct {
+	Text       []string `json:"text"`
+	TargetLang string   `json:"target_lang"`
+	SourceLang string   `json:"source_lang,omitempty"`
+	Context    string   `json:"context,omitempty"`
+	ModelType  string   `json:"model_type,omitempty"`
+}
+
+type deeplResponse struct {
+	Translations []struct {
+		DetectedSourceLanguage string `json:"detected_source_language"`
+		Text                   string `json:"text"`
+	} `json:"translations"`
+	Message string `json:"message"`
+}
+
+// deeplTargetLang turns a language code such as "en" or "pt-br" into the
+// code DeepL expects. English, Portuguese and Chinese need a variant as
+// target language.
+func deeplTargetLang(lang string) string {
+	switch code := strings.ToUpper(strings.ReplaceAll(lang, "_", "-")); code {
+	case "EN":
+		return "EN-US"
+	case "PT":
+		return "PT-BR"
+	case "ZH":
+		return "ZH-HANS"
+	default:
+		return code
+	}
+}
+
+// deeplSourceLang turns a language code into a DeepL source language,
+// which never has a region
+func deeplSourceLang(lang string) string {
+	primary, _, _ := strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-")
+	return strings.ToUpper(primary)
+}
+
+func (d *DeepLTranslator) Translate(ctx context.Context, prompt, text string) (string, error) {
+	target, source := defaultTargetLang, ""
+	langs, ok := languagesFrom(ctx)
+	if ok {
+		target, source = langs.target, langs.source
+	}
+	// The surrounding text goes in DeepL's context parameter instead of
+	// around the text
+	var surrounding string
+	if _, ok := surroundingFrom(ctx); ok {
+		if before, rest, found := strings.Cut(text, "<translate>"); found {
+			if inner, after, found := strings.Cut(rest, "</translate>"); found {
+				text, surrounding = inner, before+after
+			}
+		}
+	}
+	translated, detected, err := d.translate(ctx, text, surrounding, source, target)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		langs.detected = detected
+	}
+	return translated, nil
+}
+
+// DetectLanguage returns the ISO 639-1 code of the language DeepL finds in
+// text while translating it
+func (d *DeepLTranslator) DetectLanguage(ctx context.Context, text string) (string, error) {
+	_, detected, err := d.translate(ctx, text, "", "", defaultTargetLang)
+	if err != nil {
+		return "", err
+	}
+	return detected, nil
+}
+
+// translate returns the translation of text and the lowercase code of the
+// source language DeepL detected. surrounding is text around it that
+// helps but isn't translated.
+func (d *DeepLTranslator) translate(ctx context.Context, text, surrounding, source, target string) (string, string, error) {
+	body, err := json.Marshal(deeplRequest{
+		Text:       []string{text},
+		TargetLang: deeplTargetLang(target),
+		SourceLang: deeplSourceLang(source),
+		Context:    surrounding,
+		ModelType:  d.modelType,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	var result deeplResponse
+	if err := d.do(ctx, http.MethodPost, "/translate", body, &result); err != nil {
+		return "", "", err
+	}
+	if len(result.Translations) == 0 {
+		return "", "", fmt.Errorf("translation failed: empty response")
+	}
+	t := result.Translations[0]
+	return t.Text, strings.ToLower(t.DetectedSourceLanguage), nil
+}
+
+// deeplUsage is the answer of the usage endpoint
+type deeplUsage struct {
+	CharacterCount int64 `json:"character_count"`
+	CharacterLimit int64 `json:"character_limit"`
+}
+
+// Usage returns how many characters were translated this billing period
+// and how many the plan allows, which also checks the key
+func (d *DeepLTranslator) Usage(ctx context.Context) (deeplUsage, error) {
+	var usage deeplUsage
+	err := d.do(ctx, http.MethodGet, "/usage", nil, &usage)
+	return usage, err
+}
+
+// do sends a request to the DeepL API and decodes the answer into out
+func (d *DeepLTranslator) do(ctx context.Context, method, path string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, d.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+d.apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == deeplQuotaExceeded:
+		return errDeepLQuota
+	case resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("DeepL rejected the API key (HTTP %d)", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		var failure deeplResponse
+		if json.NewDecoder(resp.Body).Decode(&failure) == nil && failure.Message != "" {
+			return fmt.Errorf("translation failed (HTTP %d): %s", resp.StatusCode, failure.Message)
+		}
+		return fmt.Errorf("translation failed: HTTP %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
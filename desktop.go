@@ -0,0 +1,565 @@
+//go:build !headless
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/go-vgo/robotgo"
+)
+
+// desktopSupported reports whether this build can run the hotkey listener
+const desktopSupported = true
+
+// runDesktop listens for hotkeys and shows the tray icon until Quit
+func (t *TranslatorApp) runDesktop() {
+	cfg := t.currentConfig()
+	log.Println("✅ Text Translator is running...")
+	log.Printf("   Usage: Select text, then press and release %s", cfg.Hotkey)
+	log.Println("   The text will be automatically translated and pasted")
+	if runtime.GOOS == "darwin" {
+		log.Println("   Note: On macOS, you may need to grant accessibility permissions")
+	}
+	log.Println("   Press Ctrl+C or choose Quit from the tray icon to exit")
+
+	logMonitors()
+	dialogTheme = cfg.Theme
+	dialogHighContrast = cfg.KeyboardNav
+	dialogWidth, dialogHeight = fitToScreen(cfg.DialogWidth, cfg.DialogHeight)
+	dialogScale = fitScaleToScreen(cfg.UIScale)
+	t.syncStartAtLogin()
+	t.queue = newJobQueue(cfg.QueueSize, cfg.JobTTL.Std())
+	go t.queue.run()
+	go t.watchClipboard()
+	if cfg.AutoValidateKey {
+		go t.watchAPIKey()
+	}
+
+	t.runHotkeyListener()
+	t.runTray()
+}
+
+// processSelectedText translates the selected text with prompt and pastes
+// the translation over it, or with copyOnly leaves it on the clipboard
+func (t *TranslatorApp) processSelectedText(ctx context.Context, prompt Prompt, copyOnly bool) {
+	if !t.beginTranslation() {
+		slog.Warn("⚠️  Quitting, the hotkey is ignored")
+		return
+	}
+	defer t.endTranslation()
+	cfg := t.currentConfig()
+
+	// Save current clipboard content before processing
+	previousClipboard, err := clipboard.ReadAll()
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to read current clipboard: %v", err))
+		// Continue anyway - we'll just not restore it
+		previousClipboard = ""
+	}
+	if !t.enabled() {
+		log.Println("⏸  Translation is paused")
+		return
+	}
+	if !t.onAllowedMonitor() {
+		slog.Warn("⚠️  The cursor isn't on one of the allowed monitors")
+		return
+	}
+	if !t.checkBudget() {
+		return
+	}
+
+	// Read the selection directly where possible, so apps that treat
+	// Ctrl+C differently (terminals, games) aren't sent a keypress
+	var selectedText string
+	ok := false
+	if !cfg.UseSystemCopy {
+		selectedText, ok = readSelection()
+	}
+	if !ok {
+		// Copy selected text to clipboard
+		copyToClipboard()
+		time.Sleep(200 * time.Millisecond)
+
+		selectedText, err = clipboard.ReadAll()
+		if err != nil {
+			slog.Error(fmt.Sprintf("❌ Failed to read clipboard: %v", err))
+			restoreClipboard(previousClipboard)
+			return
+		}
+	}
+	selectedText = sanitiseText(selectedText)
+	if autoSelecting(ctx) && strings.TrimSpace(selectedText) != "" {
+		prompt = t.promptForText(ctx, selectedText)
+	}
+	if n := cfg.ContextLines; n > 0 && isTextEditor(activeApp()) {
+		if before, after, ok := readSurroundingText(); ok {
+			before, after = sentencesAround(before, after, n)
+			ctx = withSurroundingText(ctx, before, after)
+		}
+	}
+
+	if strings.TrimSpace(selectedText) == "" && cfg.OCREnabled {
+		selectedText = t.clipboardImageText(ctx)
+	}
+	if strings.TrimSpace(selectedText) == "" {
+		slog.Warn("⚠️  No text selected")
+		restoreClipboard(previousClipboard)
+		return
+	}
+	if !t.checkSameLanguage(prompt, selectedText) {
+		restoreClipboard(previousClipboard)
+		return
+	}
+	if t.dryRunning() {
+		t.previewDryRun(ctx, prompt, selectedText)
+		restoreClipboard(previousClipboard)
+		return
+	}
+
+	t.translateAndPaste(ctx, prompt, selectedText, previousClipboard, copyOnly)
+}
+
+// checkSameLanguage guesses whether text is already in the target language
+// of prompt, which is only known when the prompt sets TargetLang. It warns
+// and returns true to translate anyway, or with Config.SkipSameLanguage
+// returns false to skip the request.
+func (t *TranslatorApp) checkSameLanguage(prompt Prompt, text string) bool {
+	if prompt.TargetLang == "" {
+		return true
+	}
+	lang, ok := guessLanguage(text)
+	if !ok || !languageMatches(prompt.TargetLang, lang) {
+		return true
+	}
+	if t.currentConfig().SkipSameLanguage {
+		log.Printf("   Text appears to already be in %s, skipping", lang)
+		return false
+	}
+	log.Printf("   Text appears to already be in %s", lang)
+	t.notifySameLanguage(lang)
+	return true
+}
+
+// previewDryRun shows the request the first translation step would send
+// for text, without calling the API or pasting
+func (t *TranslatorApp) previewDryRun(ctx context.Context, prompt Prompt, text string) {
+	ctx, req := withDryRun(ctx)
+	if _, err := t.translateChain(ctx, prompt, text); !errors.Is(err, errDryRun) {
+		if err == nil {
+			err = errors.New("dry run: no request would be sent")
+		}
+		slog.Error(fmt.Sprintf("❌ %v", err))
+		t.notifyError(err)
+		return
+	}
+	log.Printf("   Dry run of %q, nothing was sent", prompt.Title)
+	msg := "🔴 DRY RUN — nothing was sent to the API\n\nPrompt:\n" + req.prompt + "\n\nText:\n" + req.text
+	showDialog("LingoSnap — DRY RUN", msg, "Close", "", 0)
+}
+
+func (t *TranslatorApp) dryRunning() bool {
+	return t.currentConfig().DryRun
+}
+
+// setDryRun turns dry runs on or off and saves it
+func (t *TranslatorApp) setDryRun(on bool) {
+	err := t.updateConfig(func(cfg *Config) error {
+		cfg.DryRun = on
+		return saveConfig(cfg)
+	})
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to save config: %v", err))
+	}
+}
+
+// translateAndPaste translates text, pastes the result over the focused
+// application and then puts previousClipboard back. With copyOnly the
+// result is left on the clipboard instead of pasted.
+func (t *TranslatorApp) translateAndPaste(ctx context.Context, prompt Prompt, text, previousClipboard string, copyOnly bool) {
+	cfg := t.currentConfig()
+	log.Printf("   Original: %s", truncateText(text, 50))
+
+	// Wait for the rate limit before the deadline starts, so a long wait
+	// doesn't use up the time of the translation
+	_, limiter := t.backend()
+	if err := limiter.waitForRoom(ctx, estimateTokens(prompt.Text+text)); err != nil {
+		slog.Error(fmt.Sprintf("❌ %v", err))
+		t.notifyError(err)
+		restoreClipboard(previousClipboard)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, translationTimeout(cfg, prompt))
+	defer cancel()
+	var alts *alternatives
+	if cfg.ShowAlternatives {
+		ctx, alts = withAlternatives(ctx)
+	}
+
+	start := time.Now()
+	var steps []string
+	var err error
+	if previous, ok := t.similarTranslation(prompt, text); ok {
+		log.Println("   Nearly identical to the last text, reusing its translation")
+		steps, err = []string{previous}, nil
+	} else {
+		steps, err = t.translateChain(ctx, prompt, text)
+	}
+	latency := time.Since(start)
+	if err != nil {
+		slog.Error(fmt.Sprintf("❌ %v", err))
+		t.notifyError(err)
+		restoreClipboard(previousClipboard)
+		return
+	}
+	correctedText := steps[len(steps)-1]
+
+	// Choosing between the alternatives also confirms the paste
+	chosen := false
+	if alts != nil && len(alts.texts) > 0 {
+		options := append([]string{correctedText}, alts.texts...)
+		i, ok := chooseText("LingoSnap", "Choose the translation to paste:", options)
+		if !ok {
+			log.Println("   Translation dismissed")
+			restoreClipboard(previousClipboard)
+			return
+		}
+		correctedText, chosen = options[i], true
+		steps[len(steps)-1] = correctedText
+	}
+	t.rememberTranslation(prompt, text, correctedText)
+
+	var diff string
+	mustConfirm := false
+	if cfg.BackTranslate {
+		back, err := t.backTranslate(ctx, text, correctedText)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("⚠️  %v", err))
+		} else if ops := wordDiff(text, back); hasChanges(ops) {
+			diff = formatDiff(ops)
+			log.Printf("   Back-translation differs: %s", truncateText(diff, 80))
+			mustConfirm = cfg.BackTranslateBlockOnDiff
+		}
+	}
+
+	if (cfg.ConfirmBeforePaste && !chosen) || cfg.CompareMode || mustConfirm {
+		msg := correctedText
+		if cfg.CompareMode {
+			msg = "Original:\n" + text
+			for i, step := range steps[:len(steps)-1] {
+				msg += fmt.Sprintf("\n\nStep %d:\n%s", i+1, step)
+			}
+			msg += "\n\nTranslation:\n" + correctedText
+		}
+		if diff != "" {
+			msg += "\n\nBack-translation diff:\n" + diff
+		}
+		if !showDialog("LingoSnap", msg, "Paste", "Dismiss", cfg.PopupTimeout.Std()) {
+			log.Println("   Translation dismissed")
+			restoreClipboard(previousClipboard)
+			return
+		}
+	}
+
+	// Put corrected text in clipboard and paste it
+	pasted := pastedText(cfg, text, correctedText)
+	if err := clipboard.WriteAll(pasted); err != nil {
+		slog.Error(fmt.Sprintf("❌ Failed to write to clipboard: %v", err))
+		restoreClipboard(previousClipboard)
+		return
+	}
+
+	log.Printf("   Corrected: %s", truncateText(correctedText, 50))
+	if copyOnly {
+		// The translation stays on the clipboard to be pasted by hand
+		log.Println("✅ Translation copied to the clipboard")
+		t.notifyCopied()
+	} else {
+		time.Sleep(100 * time.Millisecond)
+		pasteFromClipboard()
+		t.rememberPaste(text, pasted)
+		log.Println("✅ Text translated and pasted successfully")
+		t.notifySuccess(latency)
+	}
+	log.Printf("   %s", textStats(text, correctedText, latency))
+	log.Printf("   %s", t.GetUsageStats())
+	if spend, err := t.monthlySpend(); err == nil {
+		t.showSpend(spend)
+	}
+
+	if !copyOnly {
+		// Restore original clipboard content after a short delay
+		time.Sleep(100 * time.Millisecond)
+		t.clipboardAfterPaste(previousClipboard)
+	}
+}
+
+// clipboardAfterPaste leaves the clipboard as Config.ClipboardAfterPaste
+// asks once a translation was pasted from it
+func (t *TranslatorApp) clipboardAfterPaste(previousContent string) {
+	switch t.currentConfig().ClipboardAfterPaste {
+	case afterPasteKeepTranslation:
+	case afterPasteClear:
+		if err := clipboard.WriteAll(""); err != nil {
+			slog.Warn(fmt.Sprintf("⚠️  Failed to clear clipboard: %v", err))
+		}
+	default:
+		restoreClipboard(previousContent)
+	}
+}
+
+// setClipboardAfterPaste changes Config.ClipboardAfterPaste and saves it
+func (t *TranslatorApp) setClipboardAfterPaste(mode string) {
+	err := t.updateConfig(func(cfg *Config) error {
+		cfg.ClipboardAfterPaste = mode
+		return saveConfig(cfg)
+	})
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to save config: %v", err))
+	}
+}
+
+// enabled reports whether translation is running rather than paused
+func (t *TranslatorApp) enabled() bool {
+	return t.currentConfig().Enabled
+}
+
+// setEnabled pauses or resumes translation, saving the choice so it
+// survives a restart, and updates the tray
+func (t *TranslatorApp) setEnabled(enabled bool) {
+	err := t.updateConfig(func(cfg *Config) error {
+		cfg.Enabled = enabled
+		return saveConfig(cfg)
+	})
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to save config: %v", err))
+		return
+	}
+	t.mu.Lock()
+	onChange := t.onEnabledChange
+	t.mu.Unlock()
+
+	if enabled {
+		log.Println("▶ Translation resumed")
+	} else {
+		log.Println("⏸  Translation paused")
+	}
+	if onChange != nil {
+		onChange(enabled)
+	}
+}
+
+// startAtLogin reports whether LingoSnap starts at login
+func (t *TranslatorApp) startAtLogin() bool {
+	return t.currentConfig().StartAtLogin
+}
+
+// setStartAtLogin registers or removes the login item and saves the choice
+func (t *TranslatorApp) setStartAtLogin(enabled bool) error {
+	if err := setAutostart(enabled); err != nil {
+		return err
+	}
+	err := t.updateConfig(func(cfg *Config) error {
+		cfg.StartAtLogin = enabled
+		return saveConfig(cfg)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if enabled {
+		log.Println("✅ LingoSnap will start at login")
+	} else {
+		log.Println("   LingoSnap will no longer start at login")
+	}
+	return nil
+}
+
+// syncStartAtLogin updates Config.StartAtLogin to whether the login item
+// actually exists, since it can be removed outside LingoSnap
+func (t *TranslatorApp) syncStartAtLogin() {
+	registered := autostartEnabled()
+	if t.currentConfig().StartAtLogin == registered {
+		return
+	}
+	err := t.updateConfig(func(cfg *Config) error {
+		cfg.StartAtLogin = registered
+		return saveConfig(cfg)
+	})
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to save config: %v", err))
+	}
+}
+
+// clipboardImageText returns the text found by OCR in the image on the
+// clipboard, or "" when there is no image or no text in it
+func (t *TranslatorApp) clipboardImageText(ctx context.Context) string {
+	img, ok := readClipboardImage()
+	if !ok {
+		return ""
+	}
+	log.Println("📷 Clipboard holds an image, running OCR...")
+	text, err := t.ocrImage(ctx, img)
+	if err != nil {
+		slog.Error(fmt.Sprintf("❌ %v", err))
+		return ""
+	}
+	return text
+}
+
+// globalHotkeyPrompt returns the prompt of the app profile matching the
+// active window, falling back to the selected prompt. fromProfile reports
+// whether a profile matched.
+func (t *TranslatorApp) globalHotkeyPrompt() (_ Prompt, fromProfile bool) {
+	profiles := t.currentConfig().AppProfiles
+	if len(profiles) == 0 {
+		return t.selectedPrompt(), false
+	}
+
+	title := robotgo.GetTitle()
+	process, err := robotgo.FindName(robotgo.GetPid())
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to get the active process name: %v", err))
+	}
+	if promptTitle, ok := appProfilePrompt(profiles, process, title); ok {
+		if prompt, ok := t.findPrompt(promptTitle); ok {
+			return prompt, true
+		}
+		slog.Warn(fmt.Sprintf("⚠️  App profile for %q uses unknown prompt %q", title, promptTitle))
+	}
+	return t.selectedPrompt(), false
+}
+
+// activeApp returns the title of the focused window
+func activeApp() string {
+	return robotgo.GetTitle()
+}
+
+// rememberPaste keeps the last paste for undoLastPaste, until forgetPaste
+// drops it
+func (t *TranslatorApp) rememberPaste(original, pasted string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastOriginal, t.lastPasted, t.pastedAt = original, pasted, time.Now()
+}
+
+// cleanAnnotations strips the annotations added by Config.AnnotateMode
+// from the text on the clipboard
+func (t *TranslatorApp) cleanAnnotations() {
+	text, err := clipboard.ReadAll()
+	if err != nil {
+		slog.Error(fmt.Sprintf("❌ Failed to read clipboard: %v", err))
+		return
+	}
+	cfg := t.currentConfig()
+	cleaned := stripAnnotations(text, cfg.AnnotateOpen, cfg.AnnotateClose)
+	if cleaned == text {
+		slog.Warn("⚠️  No annotations on the clipboard")
+		return
+	}
+	if err := clipboard.WriteAll(cleaned); err != nil {
+		slog.Error(fmt.Sprintf("❌ Failed to write to clipboard: %v", err))
+		return
+	}
+	log.Println("✅ Annotations removed from the clipboard")
+}
+
+// rememberTranslation keeps the last hotkey translation for
+// similarTranslation
+func (t *TranslatorApp) rememberTranslation(prompt Prompt, input, output string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastPrompt, t.lastInput, t.lastOutput = prompt.Title, input, output
+}
+
+// similarTranslation returns the last translation when it used the same
+// prompt on text at least Config.DedupeThreshold similar to this one. A
+// threshold of 0 turns this off.
+func (t *TranslatorApp) similarTranslation(prompt Prompt, text string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	threshold := t.config.DedupeThreshold
+	if threshold <= 0 || t.lastOutput == "" || t.lastPrompt != prompt.Title {
+		return "", false
+	}
+	if !nearlyEqual(text, t.lastInput, threshold) {
+		return "", false
+	}
+	return t.lastOutput, true
+}
+
+// undoLastPaste selects the text inserted by the last paste, which is
+// assumed to end at the cursor, and pastes the original back over it.
+// Only the most recent paste can be undone.
+func (t *TranslatorApp) undoLastPaste() {
+	t.mu.Lock()
+	original, pasted := t.lastOriginal, t.lastPasted
+	t.lastOriginal, t.lastPasted = "", ""
+	t.mu.Unlock()
+
+	if pasted == "" {
+		slog.Warn("⚠️  Nothing to undo")
+		return
+	}
+	log.Println("▶ Undoing the last paste...")
+
+	previousClipboard, err := clipboard.ReadAll()
+	if err != nil {
+		previousClipboard = ""
+	}
+	// Selecting character by character stays within the pasted text,
+	// unlike select-all. The cursor moves over a whole grapheme, e.g. an
+	// emoji with its modifiers, at a time.
+	for range graphemeCount(pasted) {
+		robotgo.KeyTap("left", "shift")
+	}
+	if err := clipboard.WriteAll(original); err != nil {
+		slog.Error(fmt.Sprintf("❌ Failed to write to clipboard: %v", err))
+		return
+	}
+	time.Sleep(100 * time.Millisecond)
+	pasteFromClipboard()
+	log.Println("✅ Original text restored")
+
+	time.Sleep(100 * time.Millisecond)
+	t.clipboardAfterPaste(previousClipboard)
+}
+
+// copyToClipboard handles OS-specific copy shortcuts
+func copyToClipboard() {
+	if runtime.GOOS == "darwin" {
+		robotgo.KeyTap("c", "cmd") // macOS uses Cmd+C
+	} else {
+		robotgo.KeyTap("c", "ctrl") // Windows/Linux use Ctrl+C
+	}
+}
+
+// pasteFromClipboard handles OS-specific paste shortcuts
+func pasteFromClipboard() {
+	if runtime.GOOS == "darwin" {
+		robotgo.KeyTap("v", "cmd") // macOS uses Cmd+V
+	} else {
+		robotgo.KeyTap("v", "ctrl") // Windows/Linux use Ctrl+V
+	}
+}
+
+// restoreClipboard restores the previous clipboard content
+func restoreClipboard(previousContent string) {
+	if previousContent != "" {
+		if err := clipboard.WriteAll(previousContent); err != nil {
+			slog.Warn(fmt.Sprintf("⚠️  Failed to restore clipboard: %v", err))
+		}
+	}
+}
+
+// showWarning logs msg and shows it in a native dialog without blocking
+func showWarning(msg string) {
+	slog.Warn(fmt.Sprintf("⚠️  %s", msg))
+	go showDialog("LingoSnap", msg, "OK", "", 0)
+}
@@ -0,0 +1,199 @@
+//go:build !headless
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// xmessageFontSize is the pixel size of the xmessage font at scale 1
+const xmessageFontSize = 14
+
+// scaleDialog makes the zenity dialog run by cmd draw its text at
+// dialogScale
+func scaleDialog(cmd *exec.Cmd) {
+	if dialogScale == 1 {
+		return
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, fmt.Sprintf("GDK_DPI_SCALE=%g", dialogScale))
+}
+
+// showDialog displays msg in a native dialog and reports whether the ok
+// button was chosen. cancel may be empty for a single-button dialog, and a
+// non-zero timeout closes the dialog as if cancelled.
+//
+// zenity or xmessage is run directly rather than through robotgo.Alert,
+// which builds a shell command line from msg.
+func showDialog(title, msg, ok, cancel string, timeout time.Duration) bool {
+	var cmd *exec.Cmd
+	if path, err := exec.LookPath("zenity"); err == nil {
+		args := []string{"--title=" + title, "--text=" + msg, "--no-markup", "--ok-label=" + ok}
+		if cancel != "" {
+			args = append(args, "--question", "--cancel-label="+cancel)
+		} else {
+			args = append(args, "--info")
+		}
+		if timeout > 0 {
+			args = append(args, fmt.Sprintf("--timeout=%d", int(timeout.Seconds())))
+		}
+		if dialogWidth > 0 {
+			args = append(args, fmt.Sprintf("--width=%d", dialogWidth))
+		}
+		if dialogHeight > 0 {
+			args = append(args, fmt.Sprintf("--height=%d", dialogHeight))
+		}
+		cmd = exec.Command(path, args...)
+		// The high contrast themes draw a thick, clearly visible focus
+		// ring for keyboard navigation
+		switch {
+		case dialogHighContrast && dialogTheme == themeDark:
+			cmd.Env = append(os.Environ(), "GTK_THEME=HighContrastInverse")
+		case dialogHighContrast:
+			cmd.Env = append(os.Environ(), "GTK_THEME=HighContrast")
+		case dialogTheme == themeDark:
+			cmd.Env = append(os.Environ(), "GTK_THEME=Adwaita:dark")
+		case dialogTheme == themeLight:
+			cmd.Env = append(os.Environ(), "GTK_THEME=Adwaita")
+		}
+		scaleDialog(cmd)
+	} else if path, err := exec.LookPath("xmessage"); err == nil {
+		// -center opens xmessage around the pointer, so on the display
+		// the user is working on. zenity is placed by the window manager.
+		buttons := ok + ":0"
+		if cancel != "" {
+			buttons += "," + cancel + ":1"
+		}
+		args := []string{"-center", "-title", title, "-buttons", buttons, "-default", ok}
+		switch {
+		case dialogHighContrast:
+			// xmessage can't move focus with Tab, but Enter picks the
+			// ok button; thick yellow borders keep the buttons easy to see
+			args = append(args, "-bg", "black", "-fg", "white",
+				"-xrm", "*Command.borderColor: yellow", "-xrm", "*Command.borderWidth: 3")
+		case dialogTheme == themeDark:
+			args = append(args, "-bg", "#303030", "-fg", "#e0e0e0")
+		}
+		if timeout > 0 {
+			args = append(args, "-timeout", fmt.Sprint(int(timeout.Seconds())))
+		}
+		if dialogWidth > 0 && dialogHeight > 0 {
+			args = append(args, "-geometry", fmt.Sprintf("%dx%d", dialogWidth, dialogHeight))
+		}
+		if dialogScale != 1 {
+			args = append(args, "-fn", fmt.Sprintf("-*-*-medium-r-normal--%d-*-*-*-*-*-iso10646-1", int(xmessageFontSize*dialogScale)))
+		}
+		cmd = exec.Command(path, append(args, msg)...)
+	} else {
+		slog.Warn("⚠️  Install zenity or xmessage to see dialogs")
+		return false
+	}
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to show dialog: %v", err))
+	}
+	return err == nil
+}
+
+// sendNotification shows a desktop notification with notify-send
+func sendNotification(title, body string) {
+	path, err := exec.LookPath("notify-send")
+	if err != nil {
+		return
+	}
+	if err := exec.Command(path, "--app-name=LingoSnap", "--", title, body).Run(); err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to show notification: %v", err))
+	}
+}
+
+// askText asks for a line of text with zenity and returns it, or false
+// when the dialog was cancelled or can't be shown
+func askText(title, label string) (string, bool) {
+	return askTextDefault(title, label, "")
+}
+
+// askTextDefault is askText with the entry filled in with initial
+func askTextDefault(title, label, initial string) (string, bool) {
+	path, err := exec.LookPath("zenity")
+	if err != nil {
+		slog.Warn("⚠️  Install zenity to enter text")
+		return "", false
+	}
+	cmd := exec.Command(path, "--entry", "--title="+title, "--text="+label, "--entry-text="+initial)
+	scaleDialog(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(out)), true
+}
+
+// chooseText lists numbered options with zenity and returns the index of
+// the chosen one, or false when the dialog was cancelled. Without zenity
+// the first option is chosen.
+func chooseText(title, label string, options []string) (int, bool) {
+	path, err := exec.LookPath("zenity")
+	if err != nil {
+		slog.Warn("⚠️  Install zenity to choose between translations")
+		return 0, true
+	}
+	args := []string{"--list", "--title=" + title, "--text=" + label,
+		"--column=#", "--column=Translation", "--print-column=1",
+		fmt.Sprintf("--width=%d", int(editorWidth*dialogScale)), fmt.Sprintf("--height=%d", int(editorHeight*dialogScale))}
+	for i, option := range options {
+		args = append(args, fmt.Sprint(i+1), strings.ReplaceAll(option, "\n", " ⏎ "))
+	}
+	cmd := exec.Command(path, args...)
+	scaleDialog(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, false
+	}
+	return choiceIndex(string(out), len(options))
+}
+
+// editText shows text in an editable zenity window and returns it as
+// edited, or false when the window was cancelled or can't be shown
+func editText(title, text string) (string, bool) {
+	path, err := exec.LookPath("zenity")
+	if err != nil {
+		slog.Warn("⚠️  Install zenity to edit text")
+		return "", false
+	}
+	cmd := exec.Command(path, "--text-info", "--editable", "--title="+title,
+		fmt.Sprintf("--width=%d", int(editorWidth*dialogScale)), fmt.Sprintf("--height=%d", int(editorHeight*dialogScale)))
+	scaleDialog(cmd)
+	cmd.Stdin = strings.NewReader(text)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(string(out), "\n"), true
+}
+
+// pickFile asks for a file to open with zenity and returns its path, or
+// false when the dialog was cancelled or can't be shown
+func pickFile(title, pattern string) (string, bool) {
+	path, err := exec.LookPath("zenity")
+	if err != nil {
+		slog.Warn("⚠️  Install zenity to pick files")
+		return "", false
+	}
+	cmd := exec.Command(path, "--file-selection", "--title="+title, "--file-filter="+pattern)
+	scaleDialog(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(out)), true
+}
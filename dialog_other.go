@@ -0,0 +1,160 @@
+//go:build !linux && !headless
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/go-vgo/robotgo"
+)
+
+// showDialog displays msg in a native dialog and reports whether the ok
+// button was chosen. cancel may be empty for a single-button dialog. The
+// native alert can't close itself, so timeout is ignored, and it always
+// follows the system theme.
+func showDialog(title, msg, ok, cancel string, timeout time.Duration) bool {
+	return robotgo.Alert(title, msg, ok, cancel)
+}
+
+// sendNotification shows a desktop notification: through Notification
+// Center on macOS and a tray balloon on Windows
+func sendNotification(title, body string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)", "-e", "end run", title, body)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command",
+			`Add-Type -AssemblyName System.Windows.Forms, System.Drawing; `+
+				`$n = New-Object System.Windows.Forms.NotifyIcon; $n.Icon = [System.Drawing.SystemIcons]::Information; `+
+				`$n.Visible = $true; $n.ShowBalloonTip(5000, $env:LINGOSNAP_TITLE, $env:LINGOSNAP_BODY, "None"); `+
+				`Start-Sleep -Seconds 5; $n.Dispose()`)
+		cmd.Env = append(os.Environ(), "LINGOSNAP_TITLE="+title, "LINGOSNAP_BODY="+body)
+	default:
+		return
+	}
+	if err := cmd.Run(); err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to show notification: %v", err))
+	}
+}
+
+// inputBoxWidth and inputBoxHeight are the size of the Windows input box
+// in pixels, and twipsPerPixel converts pixels at 96 DPI to the twips its
+// position is given in
+const (
+	inputBoxWidth  = 360
+	inputBoxHeight = 160
+	twipsPerPixel  = 15
+)
+
+// askText asks for a line of text and returns it, or false when the
+// dialog was cancelled. On Windows it opens on the display under the
+// cursor; the other native dialogs are placed by the system.
+func askText(title, label string) (string, bool) {
+	return askTextDefault(title, label, "")
+}
+
+// askTextDefault is askText with the entry filled in with initial
+func askTextDefault(title, label, initial string) (string, bool) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", "on run argv",
+			"-e", `text returned of (display dialog (item 2 of argv) with title (item 1 of argv) default answer (item 3 of argv))`,
+			"-e", "end run", title, label, initial)
+	case "windows":
+		// An empty answer can't be told from Cancel, which is fine here.
+		// A position of -1 centers the box on the main display.
+		x, y := -1, -1
+		if wx, wy, ok := windowOnCursorDisplay(inputBoxWidth, inputBoxHeight); ok {
+			x, y = wx*twipsPerPixel, wy*twipsPerPixel
+		}
+		cmd = exec.Command("powershell", "-NoProfile", "-Command",
+			`Add-Type -AssemblyName Microsoft.VisualBasic; `+
+				`[Microsoft.VisualBasic.Interaction]::InputBox($env:LINGOSNAP_LABEL, $env:LINGOSNAP_TITLE, $env:LINGOSNAP_DEFAULT, [int]$env:LINGOSNAP_X, [int]$env:LINGOSNAP_Y)`)
+		cmd.Env = append(os.Environ(), "LINGOSNAP_TITLE="+title, "LINGOSNAP_LABEL="+label, "LINGOSNAP_DEFAULT="+initial,
+			fmt.Sprintf("LINGOSNAP_X=%d", x), fmt.Sprintf("LINGOSNAP_Y=%d", y))
+	default:
+		slog.Warn(fmt.Sprintf("⚠️  Entering text isn't supported on %s", runtime.GOOS))
+		return "", false
+	}
+	out, err := cmd.Output()
+	if text := strings.TrimSpace(string(out)); err == nil && text != "" {
+		return text, true
+	}
+	return "", false
+}
+
+// chooseText lists numbered options and returns the index of the chosen
+// one, or false when the dialog was cancelled
+func chooseText(title, label string, options []string) (int, bool) {
+	numbered := make([]string, len(options))
+	for i, option := range options {
+		numbered[i] = fmt.Sprintf("%d. %s", i+1, option)
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", append([]string{"-e", "on run argv",
+			"-e", "set chosen to choose from list (items 3 thru -1 of argv) with title (item 1 of argv) with prompt (item 2 of argv) default items {item 3 of argv}",
+			"-e", "if chosen is false then return \"\"",
+			"-e", "return item 1 of chosen", "-e", "end run", title, label}, numbered...)...)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command",
+			`$env:LINGOSNAP_OPTIONS.Split([char]30) | Select-Object @{n='Translation'; e={$_}} | `+
+				`Out-GridView -Title $env:LINGOSNAP_TITLE -OutputMode Single | ForEach-Object { $_.Translation }`)
+		cmd.Env = append(os.Environ(), "LINGOSNAP_TITLE="+title, "LINGOSNAP_OPTIONS="+strings.Join(numbered, "\x1e"))
+	default:
+		return 0, true
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, false
+	}
+	return choiceIndex(string(out), len(options))
+}
+
+// editText asks for the text to work on. The native dialogs only take a
+// line, so text isn't shown; an empty answer keeps it.
+func editText(title, text string) (string, bool) {
+	answer, ok := askText(title, "Text to translate (leave empty to use the clipboard):")
+	if !ok || answer == "" {
+		return text, text != ""
+	}
+	return answer, true
+}
+
+// pickFile asks for a file to open and returns its path, or false when the
+// dialog was cancelled. pattern is only used on Windows.
+func pickFile(title, pattern string) (string, bool) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", "on run argv",
+			"-e", "POSIX path of (choose file with prompt (item 1 of argv))", "-e", "end run", title)
+	case "windows":
+		// title and pattern are passed through the environment so they
+		// are never parsed as script
+		cmd = exec.Command("powershell", "-NoProfile", "-Command",
+			`Add-Type -AssemblyName System.Windows.Forms; `+
+				`$d = New-Object System.Windows.Forms.OpenFileDialog; `+
+				`$d.Title = $env:LINGOSNAP_TITLE; $d.Filter = "Files|" + $env:LINGOSNAP_PATTERN; `+
+				`if ($d.ShowDialog() -eq "OK") { $d.FileName }`)
+		cmd.Env = append(os.Environ(), "LINGOSNAP_TITLE="+title, "LINGOSNAP_PATTERN="+pattern)
+	default:
+		slog.Warn(fmt.Sprintf("⚠️  Picking files isn't supported on %s", runtime.GOOS))
+		return "", false
+	}
+	out, err := cmd.Output()
+	if path := strings.TrimSpace(string(out)); err == nil && path != "" {
+		return path, true
+	}
+	return "", false
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+// errDryRun stops a translation at the point it would call the API
+var errDryRun = errors.New("dry run: the request wasn't sent")
+
+// dryRunKey carries the request a dry run stopped at under a context
+type dryRunKey struct{}
+
+// dryRunRequest is what would have been sent to the backend, after the
+// prompt was rendered and the glossary, PII masking and pre-processing
+// were applied
+type dryRunRequest struct {
+	prompt, text string
+}
+
+// withDryRun stops translations under ctx before the API is called,
+// recording what would have been sent. They fail with errDryRun.
+func withDryRun(ctx context.Context) (context.Context, *dryRunRequest) {
+	req := &dryRunRequest{}
+	return context.WithValue(ctx, dryRunKey{}, req), req
+}
+
+// dryRunFrom returns the request recorded under a context set up by
+// withDryRun
+func dryRunFrom(ctx context.Context) (*dryRunRequest, bool) {
+	req, ok := ctx.Value(dryRunKey{}).(*dryRunRequest)
+	return req, ok
+}
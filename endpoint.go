@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const testConnectionPrompt = "Say OK"
+
+// checkCustomEndpoint rejects a Config.CustomEndpoint that isn't an
+// absolute http or https URL
+func checkCustomEndpoint(cfg *Config) error {
+	if cfg.CustomEndpoint == "" {
+		return nil
+	}
+	u, err := url.Parse(cfg.CustomEndpoint)
+	if err != nil {
+		return fmt.Errorf("invalid custom_endpoint %q: %w", cfg.CustomEndpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid custom_endpoint %q: scheme must be http or https", cfg.CustomEndpoint)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid custom_endpoint %q: missing host", cfg.CustomEndpoint)
+	}
+	return nil
+}
+
+// testConnection sends a minimal request to check that the endpoint, key
+// and model work, returning the model's reply. DeepL is asked for its
+// usage instead, which costs no characters.
+func testConnection(ctx context.Context, translator Translator) (string, error) {
+	if deepl, ok := translator.(*DeepLTranslator); ok {
+		usage, err := deepl.Usage(ctx)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d of %d characters used this billing period", usage.CharacterCount, usage.CharacterLimit), nil
+	}
+	reply, err := translator.Translate(ctx, testConnectionPrompt, "")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(reply) == "" {
+		return "", fmt.Errorf("the model returned an empty reply")
+	}
+	return reply, nil
+}
+
+// readSavedConfig parses settings.json as it is on disk now, without the
+// validation and saving of loadConfig, e.g. to test a key just typed in
+func readSavedConfig() (*Config, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, configFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	migrated, _, err := migrateConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", configFileName, err)
+	}
+	cfg := defaultConfig()
+	if err := json.Unmarshal(migrated, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", configFileName, err)
+	}
+	return cfg, nil
+}
+
+// testSavedConnection runs testConnection with the provider, endpoint and
+// key in settings.json, which may have changed since launch
+func testSavedConnection(ctx context.Context) (string, error) {
+	cfg, err := readSavedConfig()
+	if err != nil {
+		return "", err
+	}
+	translator, err := newTranslator(cfg, &usageTracker{})
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout(cfg, Prompt{}))
+	defer cancel()
+	return testConnection(ctx, translator)
+}
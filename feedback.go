@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const feedbackFileName = "feedback.jsonl"
+
+// feedbackReport describes a bad translation. The texts are only included
+// when the user agrees; TextSHA256 is always sent so reports of the same
+// text can be grouped.
+type feedbackReport struct {
+	Time        time.Time `json:"time"`
+	Problem     string    `json:"problem"`
+	Model       string    `json:"model"`
+	TextSHA256  string    `json:"text_sha256"`
+	Original    string    `json:"original,omitempty"`
+	Translation string    `json:"translation,omitempty"`
+	Prompt      string    `json:"prompt,omitempty"`
+}
+
+// newFeedbackReport builds a report on the translation of original with
+// the given prompt title, leaving out the texts unless includeText is set
+func newFeedbackReport(cfg *Config, problem, original, translation, prompt string, includeText bool) feedbackReport {
+	sum := sha256.Sum256([]byte(original))
+	report := feedbackReport{
+		Time:       time.Now(),
+		Problem:    problem,
+		Model:      cfg.Model,
+		TextSHA256: hex.EncodeToString(sum[:]),
+	}
+	if includeText {
+		report.Original, report.Translation, report.Prompt = original, translation, prompt
+	}
+	return report
+}
+
+// reportHistoryEntry sends feedback on the newest history entry whose
+// original or translated text contains query
+func reportHistoryEntry(cfg *Config, query, problem string, includeText bool) error {
+	if problem == "" {
+		return fmt.Errorf("-problem is required with -report-history")
+	}
+	history, err := openHistory(cfg.MaxHistory)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer history.Close()
+
+	entries, err := history.Search(query, 1)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no history entry contains %q", query)
+	}
+	e := entries[0]
+	report := newFeedbackReport(cfg, problem, e.Original, e.Translated, e.PromptTitle, includeText)
+	report.Model = e.Model
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout(cfg, Prompt{}))
+	defer cancel()
+	return sendFeedback(ctx, cfg, report)
+}
+
+// sendFeedback posts report as JSON to Config.FeedbackURL, or appends it
+// to feedback.jsonl in the config directory when no URL is set
+func sendFeedback(ctx context.Context, cfg *Config, report feedbackReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode feedback: %w", err)
+	}
+
+	if cfg.FeedbackURL == "" {
+		dir, err := configDir()
+		if err != nil {
+			return err
+		}
+		f, err := os.OpenFile(filepath.Join(dir, feedbackFileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", feedbackFileName, err)
+		}
+		defer f.Close()
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("failed to write %s: %w", feedbackFileName, err)
+		}
+		return nil
+	}
+
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.FeedbackURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to send feedback: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send feedback: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to send feedback: %s", resp.Status)
+	}
+	return nil
+}
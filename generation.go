@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"google.golang.org/genai"
+)
+
+var harmCategories = []genai.HarmCategory{
+	genai.HarmCategoryHarassment,
+	genai.HarmCategoryHateSpeech,
+	genai.HarmCategorySexuallyExplicit,
+	genai.HarmCategoryDangerousContent,
+	genai.HarmCategoryCivicIntegrity,
+}
+
+var harmThresholds = []genai.HarmBlockThreshold{
+	genai.HarmBlockThresholdBlockLowAndAbove,
+	genai.HarmBlockThresholdBlockMediumAndAbove,
+	genai.HarmBlockThresholdBlockOnlyHigh,
+	genai.HarmBlockThresholdBlockNone,
+	genai.HarmBlockThresholdOff,
+}
+
+// generateConfig holds the generation options taken from the config
+func generateConfig(cfg *Config) *genai.GenerateContentConfig {
+	return &genai.GenerateContentConfig{
+		Temperature:    genai.Ptr(cfg.Temperature),
+		TopP:           genai.Ptr(cfg.TopP),
+		SafetySettings: safetySettings(cfg),
+	}
+}
+
+// checkGenerationSettings rejects out-of-range sampling parameters and
+// unknown harm categories and thresholds
+func checkGenerationSettings(cfg *Config) error {
+	if cfg.Temperature < 0 || cfg.Temperature > 2 {
+		return fmt.Errorf("temperature must be between 0 and 2, got %g", cfg.Temperature)
+	}
+	if cfg.TopP < 0 || cfg.TopP > 1 {
+		return fmt.Errorf("top_p must be between 0 and 1, got %g", cfg.TopP)
+	}
+	for category, threshold := range cfg.SafetySettings {
+		if !slices.Contains(harmCategories, genai.HarmCategory(category)) {
+			return fmt.Errorf("unknown safety category %q", category)
+		}
+		if !slices.Contains(harmThresholds, genai.HarmBlockThreshold(threshold)) {
+			return fmt.Errorf("unknown safety threshold %q for %s", threshold, category)
+		}
+	}
+	return nil
+}
+
+// safetySettings converts Config.SafetySettings for the Gemini API;
+// categories left out keep the API defaults
+func safetySettings(cfg *Config) []*genai.SafetySetting {
+	var settings []*genai.SafetySetting
+	for _, category := range harmCategories {
+		if threshold, ok := cfg.SafetySettings[string(category)]; ok {
+			settings = append(settings, &genai.SafetySetting{
+				Category:  category,
+				Threshold: genai.HarmBlockThreshold(threshold),
+			})
+		}
+	}
+	return settings
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GlossaryEntry fixes the translation of a term. An empty Target keeps
+// Source untranslated, which suits brand names and proper nouns.
+type GlossaryEntry struct {
+	Source string `json:"source"`
+	Target string `json:"target,omitempty"`
+}
+
+// glossaryTerm is a glossary entry found in the text being translated
+type glossaryTerm struct {
+	placeholder string
+	target      string
+}
+
+// mergeGlossary returns existing followed by the entries of added whose
+// term, compared case-insensitively, isn't in it yet
+func mergeGlossary(existing, added []GlossaryEntry) []GlossaryEntry {
+	merged := slices.Clone(existing)
+	for _, e := range added {
+		if !slices.ContainsFunc(merged, func(g GlossaryEntry) bool { return strings.EqualFold(g.Source, e.Source) }) {
+			merged = append(merged, e)
+		}
+	}
+	return merged
+}
+
+// applyGlossary replaces every glossary term in text with a placeholder,
+// matching case-insensitively on whole words only. It returns the masked
+// text, the prompt instruction describing the placeholders and the terms
+// that restoreGlossary needs.
+func applyGlossary(text string, glossary []GlossaryEntry) (string, string, []glossaryTerm) {
+	// Longer terms go first so "New York Times" wins over "New York"
+	entries := slices.Clone(glossary)
+	slices.SortStableFunc(entries, func(a, b GlossaryEntry) int {
+		return cmp.Compare(len(b.Source), len(a.Source))
+	})
+
+	var terms []glossaryTerm
+	for _, e := range entries {
+		source := strings.TrimSpace(e.Source)
+		if source == "" {
+			continue
+		}
+		placeholder := glossaryPlaceholder(len(terms))
+		masked, found := replaceWord(text, source, placeholder)
+		if !found {
+			continue
+		}
+		text = masked
+		target := e.Target
+		if target == "" {
+			target = source
+		}
+		terms = append(terms, glossaryTerm{placeholder: placeholder, target: target})
+	}
+	if len(terms) == 0 {
+		return text, "", nil
+	}
+
+	var instruction strings.Builder
+	instruction.WriteString("\n\nCopy these placeholders verbatim; each stands for a fixed term:")
+	for _, term := range terms {
+		fmt.Fprintf(&instruction, "\n%s = %q", term.placeholder, term.target)
+	}
+	return text, instruction.String(), terms
+}
+
+// restoreGlossary substitutes the glossary targets for the placeholders
+// left in the translation
+func restoreGlossary(text string, terms []glossaryTerm) string {
+	for _, term := range terms {
+		text = strings.ReplaceAll(text, term.placeholder, term.target)
+	}
+	return text
+}
+
+// replaceWord replaces the case-insensitive occurrences of word in text
+// that are not part of a longer word. \b only understands ASCII, so the
+// boundaries are checked by hand to work for Armenian and other scripts.
+func replaceWord(text, word, replacement string) (string, bool) {
+	pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(word))
+
+	var b strings.Builder
+	last, found := 0, false
+	for _, m := range pattern.FindAllStringIndex(text, -1) {
+		if !isWordBoundary(text, m[0], m[1]) {
+			continue
+		}
+		b.WriteString(text[last:m[0]])
+		b.WriteString(replacement)
+		last, found = m[1], true
+	}
+	b.WriteString(text[last:])
+	return b.String(), found
+}
+
+// isWordBoundary reports whether text[start:end] is neither preceded nor
+// followed by a letter or digit
+func isWordBoundary(text string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	after, _ := utf8.DecodeRuneInString(text[end:])
+	return !isWordRune(before) && !isWordRune(after)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func glossaryPlaceholder(i int) string {
+	return fmt.Sprintf("__TERM_%d__", i)
+}
@@ -0,0 +1,17 @@
+//go:build headless
+
+package main
+
+// desktopSupported reports whether this build can run the hotkey listener
+const desktopSupported = false
+
+// activeApp is unknown without a desktop session
+func activeApp() string {
+	return ""
+}
+
+// runDesktop is unavailable in headless builds, which leave out the
+// keyboard, clipboard and tray dependencies
+func (t *TranslatorApp) runDesktop() {
+	fatal("This build has no desktop support, use -cli")
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const historyFileName = "history.db"
+
+// History is a single translation recorded in the history database
+type History struct {
+	ID          int64
+	Timestamp   time.Time
+	Original    string
+	Translated  string
+	PromptTitle string
+	Model       string
+	Latency     time.Duration
+	SourceLang  string // empty unless the language was detected
+	TargetLang  string
+
+	// Alternatives are the other candidate translations shown with
+	// Config.ShowAlternatives
+	Alternatives []string
+
+	// Flags is why the model stopped, when it wasn't the normal end of
+	// the answer, e.g. MAX_TOKENS or SAFETY
+	Flags string
+}
+
+// HistoryStore persists translations to an SQLite file, keeping at most
+// maxEntries rows
+type HistoryStore struct {
+	db         *sql.DB
+	maxEntries int
+}
+
+// openHistory opens (or creates) history.db in the config directory
+func openHistory(maxEntries int) (*HistoryStore, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	return openHistoryFile(filepath.Join(dir, historyFileName), maxEntries)
+}
+
+// openHistoryFile opens (or creates) the history database at path
+func openHistoryFile(path string, maxEntries int) (*HistoryStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS history (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp    DATETIME NOT NULL,
+		original     TEXT NOT NULL,
+		translated   TEXT NOT NULL,
+		prompt_title TEXT NOT NULL,
+		model        TEXT NOT NULL,
+		latency_ms   INTEGER NOT NULL,
+		source_lang  TEXT NOT NULL DEFAULT '',
+		target_lang  TEXT NOT NULL DEFAULT '',
+		alternatives TEXT NOT NULL DEFAULT '',
+		flags        TEXT NOT NULL DEFAULT ''
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create history table: %w", err)
+	}
+	if err := addMissingColumns(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS usage (
+		timestamp     DATETIME NOT NULL,
+		model         TEXT NOT NULL,
+		input_tokens  INTEGER NOT NULL,
+		output_tokens INTEGER NOT NULL,
+		cost_usd      REAL NOT NULL
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create usage table: %w", err)
+	}
+	if err := createStatsTable(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &HistoryStore{db: db, maxEntries: maxEntries}, nil
+}
+
+// createStatsTable creates the table statistics are computed from. Like
+// usage it is never pruned, so statistics cover every translation, not
+// just the last max_history. A new table starts with the history there is.
+func createStatsTable(db *sql.DB) error {
+	var exists int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'stats'`).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to read history tables: %w", err)
+	}
+	if exists > 0 {
+		return nil
+	}
+
+	_, err = db.Exec(`CREATE TABLE stats (
+		timestamp   DATETIME NOT NULL,
+		model       TEXT NOT NULL,
+		latency_ms  INTEGER NOT NULL,
+		source_lang TEXT NOT NULL,
+		target_lang TEXT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create stats table: %w", err)
+	}
+	_, err = db.Exec(`INSERT INTO stats (timestamp, model, latency_ms, source_lang, target_lang)
+		SELECT timestamp, model, latency_ms, source_lang, target_lang FROM history ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to fill stats table: %w", err)
+	}
+	return nil
+}
+
+// statsInsert adds a translation to the stats table
+const statsInsert = `INSERT INTO stats (timestamp, model, latency_ms, source_lang, target_lang) VALUES (?, ?, ?, ?, ?)`
+
+// addMissingColumns adds the language, alternatives and flags columns to
+// history tables created before they existed
+func addMissingColumns(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('history')`)
+	if err != nil {
+		return fmt.Errorf("failed to read history table: %w", err)
+	}
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read history table: %w", err)
+		}
+		columns[name] = true
+	}
+	rows.Close()
+
+	for _, column := range []string{"source_lang", "target_lang", "alternatives", "flags"} {
+		if columns[column] {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE history ADD COLUMN ` + column + ` TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("failed to add %s to history: %w", column, err)
+		}
+	}
+	return nil
+}
+
+// Add records a translation and prunes the oldest entries beyond the cap
+func (h *HistoryStore) Add(entry History) error {
+	_, err := h.db.Exec(
+		`INSERT INTO history (timestamp, original, translated, prompt_title, model, latency_ms, source_lang, target_lang, alternatives, flags)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Timestamp, entry.Original, entry.Translated,
+		entry.PromptTitle, entry.Model, entry.Latency.Milliseconds(),
+		entry.SourceLang, entry.TargetLang, encodeAlternatives(entry.Alternatives), entry.Flags,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert history entry: %w", err)
+	}
+	_, err = h.db.Exec(statsInsert, entry.Timestamp, entry.Model, entry.Latency.Milliseconds(), entry.SourceLang, entry.TargetLang)
+	if err != nil {
+		return fmt.Errorf("failed to record statistics: %w", err)
+	}
+
+	if h.maxEntries > 0 {
+		_, err = h.db.Exec(
+			`DELETE FROM history WHERE id NOT IN (
+				SELECT id FROM history ORDER BY id DESC LIMIT ?
+			)`, h.maxEntries)
+		if err != nil {
+			return fmt.Errorf("failed to prune history: %w", err)
+		}
+	}
+	return nil
+}
+
+// Import adds entries, given oldest first, in one transaction and prunes
+// the history once. With replace the existing entries and their
+// statistics are deleted first.
+func (h *HistoryStore) Import(entries []History, replace bool) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to import history: %w", err)
+	}
+	defer tx.Rollback()
+
+	if replace {
+		if _, err := tx.Exec(`DELETE FROM history`); err != nil {
+			return fmt.Errorf("failed to clear history: %w", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM stats`); err != nil {
+			return fmt.Errorf("failed to clear statistics: %w", err)
+		}
+	}
+	for _, e := range entries {
+		_, err := tx.Exec(
+			`INSERT INTO history (timestamp, original, translated, prompt_title, model, latency_ms, source_lang, target_lang, alternatives, flags)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			e.Timestamp, e.Original, e.Translated, e.PromptTitle, e.Model,
+			e.Latency.Milliseconds(), e.SourceLang, e.TargetLang, encodeAlternatives(e.Alternatives), e.Flags,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert history entry: %w", err)
+		}
+		if _, err := tx.Exec(statsInsert, e.Timestamp, e.Model, e.Latency.Milliseconds(), e.SourceLang, e.TargetLang); err != nil {
+			return fmt.Errorf("failed to record statistics: %w", err)
+		}
+	}
+	if h.maxEntries > 0 {
+		_, err = tx.Exec(
+			`DELETE FROM history WHERE id NOT IN (
+				SELECT id FROM history ORDER BY id DESC LIMIT ?
+			)`, h.maxEntries)
+		if err != nil {
+			return fmt.Errorf("failed to prune history: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SnapshotTo writes a consistent copy of the database to path, which must
+// not exist yet
+func (h *HistoryStore) SnapshotTo(path string) error {
+	if _, err := h.db.Exec(`VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("failed to copy history: %w", err)
+	}
+	return nil
+}
+
+// encodeAlternatives stores alternatives as a JSON array, or an empty
+// string when there are none
+func encodeAlternatives(alternatives []string) string {
+	if len(alternatives) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(alternatives)
+	return string(data)
+}
+
+// Search returns the newest entries whose original or translated text
+// contains query. An empty query matches everything.
+func (h *HistoryStore) Search(query string, limit int) ([]History, error) {
+	rows, err := h.db.Query(
+		`SELECT id, timestamp, original, translated, prompt_title, model, latency_ms, source_lang, target_lang, alternatives, flags
+		FROM history
+		WHERE original LIKE '%' || ? || '%' OR translated LIKE '%' || ? || '%'
+		ORDER BY id DESC LIMIT ?`,
+		query, query, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []History
+	for rows.Next() {
+		var e History
+		var latencyMs int64
+		var alternatives string
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.Original, &e.Translated,
+			&e.PromptTitle, &e.Model, &latencyMs, &e.SourceLang, &e.TargetLang, &alternatives, &e.Flags); err != nil {
+			return nil, fmt.Errorf("failed to read history entry: %w", err)
+		}
+		e.Latency = time.Duration(latencyMs) * time.Millisecond
+		if alternatives != "" {
+			json.Unmarshal([]byte(alternatives), &e.Alternatives)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// LanguagePairCount is the number of translations between two languages
+type LanguagePairCount struct {
+	SourceLang string `json:"source_lang"`
+	TargetLang string `json:"target_lang"`
+	Count      int    `json:"count"`
+}
+
+// LanguagePairs returns the limit most used language pairs of the
+// translations made since the given time, most used first. Translations
+// pruned from the history still count.
+func (h *HistoryStore) LanguagePairs(since time.Time, limit int) ([]LanguagePairCount, error) {
+	rows, err := h.db.Query(
+		`SELECT source_lang, target_lang, COUNT(*) AS n
+		FROM stats
+		WHERE timestamp >= ?
+		GROUP BY source_lang, target_lang
+		ORDER BY n DESC, source_lang, target_lang LIMIT ?`,
+		// Timestamps are stored as local time strings, so compare alike
+		since.Local(), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query language pairs: %w", err)
+	}
+	defer rows.Close()
+
+	var pairs []LanguagePairCount
+	for rows.Next() {
+		var p LanguagePairCount
+		if err := rows.Scan(&p.SourceLang, &p.TargetLang, &p.Count); err != nil {
+			return nil, fmt.Errorf("failed to read language pair: %w", err)
+		}
+		pairs = append(pairs, p)
+	}
+	return pairs, rows.Err()
+}
+
+// Latencies returns the latencies of the translations made since the given
+// time by model, shortest first, including those pruned from the history.
+// A non-empty model only returns its own.
+func (h *HistoryStore) Latencies(since time.Time, model string) (map[string][]time.Duration, error) {
+	rows, err := h.db.Query(
+		`SELECT model, latency_ms FROM stats
+		WHERE timestamp >= ? AND (? = '' OR model = ?)
+		ORDER BY model, latency_ms`,
+		// Timestamps are stored as local time strings, so compare alike
+		since.Local(), model, model,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latencies: %w", err)
+	}
+	defer rows.Close()
+
+	latencies := make(map[string][]time.Duration)
+	for rows.Next() {
+		var m string
+		var latencyMs int64
+		if err := rows.Scan(&m, &latencyMs); err != nil {
+			return nil, fmt.Errorf("failed to read latency: %w", err)
+		}
+		latencies[m] = append(latencies[m], time.Duration(latencyMs)*time.Millisecond)
+	}
+	return latencies, rows.Err()
+}
+
+// AddUsage records the tokens and estimated cost of an API call. Unlike
+// translations, usage is never pruned, so spend adds up across sessions.
+func (h *HistoryStore) AddUsage(at time.Time, model string, input, output int64, costUSD float64) error {
+	_, err := h.db.Exec(
+		`INSERT INTO usage (timestamp, model, input_tokens, output_tokens, cost_usd) VALUES (?, ?, ?, ?, ?)`,
+		at, model, input, output, costUSD,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// SpendSince returns the estimated USD cost of the API calls made since
+// the given time
+func (h *HistoryStore) SpendSince(since time.Time) (float64, error) {
+	var total float64
+	err := h.db.QueryRow(
+		`SELECT COALESCE(SUM(cost_usd), 0) FROM usage WHERE timestamp >= ?`,
+		// Timestamps are stored as local time strings, so compare alike
+		since.Local(),
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query usage: %w", err)
+	}
+	return total, nil
+}
+
+// Close releases the database handle
+func (h *HistoryStore) Close() error {
+	return h.db.Close()
+}
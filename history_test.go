@@ -0,0 +1,84 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStatisticsOutlivePruning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), historyFileName)
+	h, err := openHistoryFile(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, target := range []string{"de", "de", "fr"} {
+		err := h.Add(History{
+			Timestamp: time.Now(), Original: "hello", Translated: "hallo", Model: "gemini-2.0-flash",
+			Latency: time.Duration(i+1) * time.Second, SourceLang: "en", TargetLang: target,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func() {
+		t.Helper()
+		entries, err := h.Search("", 10)
+		if err != nil || len(entries) != 2 {
+			t.Fatalf("Search() = %d entries, %v, want the 2 kept", len(entries), err)
+		}
+		pairs, err := h.LanguagePairs(time.Time{}, statsTopPairs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []LanguagePairCount{{"en", "de", 2}, {"en", "fr", 1}}
+		if len(pairs) != len(want) || pairs[0] != want[0] || pairs[1] != want[1] {
+			t.Errorf("LanguagePairs() = %+v, want %+v", pairs, want)
+		}
+		latencies, err := h.Latencies(time.Time{}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := latencies["gemini-2.0-flash"]; len(got) != 3 || got[0] != time.Second {
+			t.Errorf("Latencies() = %v, want all 3 translations", got)
+		}
+	}
+	check()
+
+	// Reopening keeps the table instead of filling it from the history again
+	h.Close()
+	if h, err = openHistoryFile(path, 2); err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	check()
+}
+
+func TestStatisticsStartFromTheHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), historyFileName)
+	h, err := openHistoryFile(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Add(History{Timestamp: time.Now(), Model: "gpt-4o", SourceLang: "en", TargetLang: "hy"}); err != nil {
+		t.Fatal(err)
+	}
+	// A database from before the stats table
+	if _, err := h.db.Exec(`DROP TABLE stats`); err != nil {
+		t.Fatal(err)
+	}
+	h.Close()
+
+	if h, err = openHistoryFile(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	pairs, err := h.LanguagePairs(time.Time{}, statsTopPairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 1 || pairs[0] != (LanguagePairCount{"en", "hy", 1}) {
+		t.Errorf("LanguagePairs() = %+v, want the translation already in the history", pairs)
+	}
+}
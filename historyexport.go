@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats accepted by -history-format
+const (
+	historyCSV  = "csv"
+	historyAnki = "anki"
+)
+
+// ankiHeader tells Anki's importer how to read the file
+const ankiHeader = "#separator:tab\n#html:false\n#tags column:3\n"
+
+// exportHistory writes every history entry, or with flaggedOnly those with
+// Flags, to path as CSV or as an Anki deck and returns the number of
+// entries written
+func exportHistory(h *HistoryStore, path, format string, ankiMaxLen int, flaggedOnly bool) (int, error) {
+	if format != historyCSV && format != historyAnki {
+		return 0, fmt.Errorf("unknown history format %q, use %q or %q", format, historyCSV, historyAnki)
+	}
+	entries, err := h.Search("", -1)
+	if err != nil {
+		return 0, err
+	}
+	if flaggedOnly {
+		entries = slices.DeleteFunc(entries, func(e History) bool { return e.Flags == "" })
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if format == historyAnki {
+		err = writeAnkiDeck(f, entries, ankiMaxLen)
+	} else {
+		err = writeHistoryCSV(f, entries)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return len(entries), f.Close()
+}
+
+// writeHistoryCSV writes entries with a header row
+func writeHistoryCSV(w io.Writer, entries []History) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "source", "target", "prompt", "model", "latency_ms", "flags"})
+	for _, e := range entries {
+		cw.Write([]string{
+			e.Timestamp.Format(time.RFC3339),
+			e.Original,
+			e.Translated,
+			e.PromptTitle,
+			e.Model,
+			strconv.FormatInt(e.Latency.Milliseconds(), 10),
+			e.Flags,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeAnkiDeck writes entries as tab-separated notes with the original on
+// the front, the translation on the back and the prompt title as tag.
+// Texts longer than maxLen runes are cut with an ellipsis.
+func writeAnkiDeck(w io.Writer, entries []History, maxLen int) error {
+	if _, err := io.WriteString(w, ankiHeader); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = '\t'
+	for _, e := range entries {
+		cw.Write([]string{
+			truncateRunes(e.Original, maxLen),
+			truncateRunes(e.Translated, maxLen),
+			// Anki tags are separated by spaces
+			strings.ReplaceAll(e.PromptTitle, " ", "_"),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// truncateRunes cuts s to maxLen runes, ending it with an ellipsis. A
+// maxLen of 0 or less keeps s whole.
+func truncateRunes(s string, maxLen int) string {
+	runes := []rune(s)
+	if maxLen <= 0 || len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen-1]) + "…"
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/csv"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var exportEntries = []History{
+	{
+		Timestamp:   time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC),
+		Original:    "Hello, world",
+		Translated:  `Say "hi"`,
+		PromptTitle: "Formal tone",
+		Model:       "gemini-2.0-flash",
+		Latency:     1500 * time.Millisecond,
+	},
+	{
+		Timestamp:   time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC),
+		Original:    "line one\nline two",
+		Translated:  "tab\there, \"both\"",
+		PromptTitle: "Plain",
+		Model:       "gpt-4o",
+		Latency:     20 * time.Millisecond,
+		Flags:       "wrong",
+	},
+}
+
+func TestWriteHistoryCSV(t *testing.T) {
+	var out strings.Builder
+	if err := writeHistoryCSV(&out, exportEntries); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `time,source,target,prompt,model,latency_ms,flags
+2026-03-01T09:30:00Z,"Hello, world","Say ""hi""",Formal tone,gemini-2.0-flash,1500,
+2026-03-02T10:00:00Z,"line one
+line two","tab	here, ""both""",Plain,gpt-4o,20,wrong
+`
+	if out.String() != want {
+		t.Errorf("writeHistoryCSV() wrote\n%s\nwant\n%s", out.String(), want)
+	}
+
+	// The file reads back into the same fields
+	records, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("output doesn't parse as CSV: %v", err)
+	}
+	if len(records) != len(exportEntries)+1 {
+		t.Fatalf("got %d records, want %d", len(records), len(exportEntries)+1)
+	}
+	for i, e := range exportEntries {
+		got := records[i+1]
+		if got[1] != e.Original || got[2] != e.Translated || got[6] != e.Flags {
+			t.Errorf("record %d = %q, want the fields of %+v", i+1, got, e)
+		}
+	}
+}
+
+func TestWriteAnkiDeck(t *testing.T) {
+	var out strings.Builder
+	if err := writeAnkiDeck(&out, exportEntries, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Commas need no quoting with tabs as separator
+	want := ankiHeader +
+		"Hello, world\t\"Say \"\"hi\"\"\"\tFormal_tone\n" +
+		"\"line one\nline two\"\t\"tab\there, \"\"both\"\"\"\tPlain\n"
+	if out.String() != want {
+		t.Errorf("writeAnkiDeck() wrote\n%q\nwant\n%q", out.String(), want)
+	}
+
+	body := strings.TrimPrefix(out.String(), ankiHeader)
+	r := csv.NewReader(strings.NewReader(body))
+	r.Comma = '\t'
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("notes don't parse as TSV: %v", err)
+	}
+	for i, e := range exportEntries {
+		want := []string{e.Original, e.Translated, strings.ReplaceAll(e.PromptTitle, " ", "_")}
+		if !slices.Equal(records[i], want) {
+			t.Errorf("note %d = %q, want %q", i+1, records[i], want)
+		}
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		s      string
+		maxLen int
+		want   string
+	}{
+		{"hello", 0, "hello"},
+		{"hello", 5, "hello"},
+		{"hello world", 5, "hell…"},
+		{"Բարև ձեզ", 4, "Բար…"},
+	}
+	for _, tt := range tests {
+		if got := truncateRunes(tt.s, tt.maxLen); got != tt.want {
+			t.Errorf("truncateRunes(%q, %d) = %q, want %q", tt.s, tt.maxLen, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+const defaultHotkey = "rshift"
+
+// defaultSettingsHotkey opens settings.json
+const defaultSettingsHotkey = "ctrl+alt+s"
+
+// parseHotkey splits a combination such as "ctrl+shift+t" into key names
+func parseHotkey(hotkey string) []string {
+	var keys []string
+	for _, key := range strings.Split(strings.ToLower(hotkey), "+") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// normalizeHotkey returns a canonical form so that "shift+ctrl+t" and
+// "Ctrl+Shift+T" compare equal
+func normalizeHotkey(hotkey string) string {
+	keys := parseHotkey(hotkey)
+	slices.Sort(keys)
+	return strings.Join(keys, "+")
+}
+
+// checkHotkeyConflicts reports prompts that share a hotkey with each other
+// or with the global hotkey
+func checkHotkeyConflicts(cfg *Config) error {
+	owners := map[string]string{normalizeHotkey(cfg.Hotkey): "the global hotkey"}
+	for _, h := range []struct{ name, hotkey string }{
+		{"the OCR hotkey", cfg.OCRHotkey},
+		{"the copy hotkey", cfg.CopyHotkey},
+		{"the undo hotkey", cfg.UndoHotkey},
+		{"the pause hotkey", cfg.PauseHotkey},
+		{"the settings hotkey", cfg.SettingsHotkey},
+		{"the annotation cleanup hotkey", cfg.AnnotateCleanupHotkey},
+	} {
+		if h.hotkey == "" {
+			continue
+		}
+		key := normalizeHotkey(h.hotkey)
+		if owner, ok := owners[key]; ok {
+			return fmt.Errorf("%s %q is already assigned to %s", h.name, h.hotkey, owner)
+		}
+		owners[key] = h.name
+	}
+	for _, p := range cfg.Prompts {
+		if p.Hotkey == "" {
+			continue
+		}
+		key := normalizeHotkey(p.Hotkey)
+		if owner, ok := owners[key]; ok {
+			return fmt.Errorf("prompt %q uses hotkey %q, which is already assigned to %s", p.Title, p.Hotkey, owner)
+		}
+		owners[key] = fmt.Sprintf("prompt %q", p.Title)
+	}
+	return nil
+}
+
+// reservedHotkeys are the shortcuts each OS keeps for itself; registering
+// them either fails or breaks the system shortcut
+var reservedHotkeys = map[string][]string{
+	"darwin": {
+		"cmd+c", "cmd+v", "cmd+x", "cmd+z", "cmd+a", "cmd+q", "cmd+w", "cmd+h", "cmd+m",
+		"cmd+tab", "cmd+space", "ctrl+space", "cmd+alt+esc", "ctrl+cmd+q",
+		"cmd+shift+3", "cmd+shift+4", "cmd+shift+5",
+	},
+	"windows": {
+		"ctrl+c", "ctrl+v", "ctrl+x", "ctrl+z", "ctrl+a", "alt+tab", "alt+f4",
+		"ctrl+alt+delete", "ctrl+shift+esc", "ctrl+esc",
+		"cmd+l", "cmd+d", "cmd+e", "cmd+r", "cmd+tab",
+	},
+	"linux": {
+		"ctrl+c", "ctrl+v", "ctrl+x", "ctrl+z", "ctrl+a", "alt+tab", "alt+f4",
+		"ctrl+alt+delete", "ctrl+alt+t", "cmd+l", "cmd+tab",
+	},
+}
+
+// validateHotkey rejects empty hotkeys and combinations reserved by the
+// current OS
+func validateHotkey(hotkey string) error {
+	key := normalizeHotkey(hotkey)
+	if key == "" {
+		return fmt.Errorf("hotkey is empty")
+	}
+	for _, reserved := range reservedHotkeys[runtime.GOOS] {
+		if normalizeHotkey(reserved) == key {
+			return fmt.Errorf("%s is reserved by the operating system", hotkey)
+		}
+	}
+	return nil
+}
+
+// commonShortcuts are combinations most applications already use
+var commonShortcuts = []string{
+	"ctrl+y", "ctrl+s", "ctrl+f", "ctrl+p", "ctrl+n", "ctrl+t", "ctrl+w",
+	"cmd+s", "cmd+f", "cmd+p", "cmd+n", "cmd+t",
+}
+
+// isCommonShortcut reports whether hotkey would shadow a common shortcut
+func isCommonShortcut(hotkey string) bool {
+	key := normalizeHotkey(hotkey)
+	for _, s := range commonShortcuts {
+		if normalizeHotkey(s) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// coolingDown reports whether hotkey fired less than HotkeyCooldownMs
+// ago, which usually means an accidental double press; otherwise it
+// records the trigger. Each hotkey cools down on its own, so a different
+// one pressed right after still fires.
+func (t *TranslatorApp) coolingDown(hotkey string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := normalizeHotkey(hotkey)
+	cooldown := time.Duration(t.config.HotkeyCooldownMs) * time.Millisecond
+	if time.Since(t.lastTriggers[key]) < cooldown {
+		return true
+	}
+	if t.lastTriggers == nil {
+		t.lastTriggers = make(map[string]time.Time)
+	}
+	t.lastTriggers[key] = time.Now()
+	return false
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCoolingDown(t *testing.T) {
+	app := &TranslatorApp{config: defaultConfig()}
+	app.config.HotkeyCooldownMs = 60_000
+
+	if app.coolingDown("ctrl+shift+t") {
+		t.Fatal("first press was cooling down")
+	}
+	if !app.coolingDown("ctrl+shift+t") {
+		t.Error("second press of the same hotkey wasn't cooling down")
+	}
+	if !app.coolingDown("Shift+Ctrl+T") {
+		t.Error("the same hotkey spelled differently wasn't cooling down")
+	}
+	if app.coolingDown("rshift") {
+		t.Error("another hotkey was cooling down")
+	}
+	if app.coolingDown("ctrl+alt+z") {
+		t.Error("a third hotkey was cooling down")
+	}
+
+	app.config.HotkeyCooldownMs = 1
+	time.Sleep(5 * time.Millisecond)
+	if app.coolingDown("ctrl+shift+t") {
+		t.Error("hotkey still cooling down after the cooldown")
+	}
+}
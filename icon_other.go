@@ -0,0 +1,11 @@
+//go:build !windows && !headless
+
+package main
+
+import _ "embed"
+
+//go:embed assets/icon.png
+var trayIcon []byte
+
+//go:embed assets/icon_paused.png
+var trayIconPaused []byte
@@ -0,0 +1,13 @@
+//go:build !headless
+
+package main
+
+import _ "embed"
+
+// The Windows tray only accepts ICO files
+//
+//go:embed assets/icon.ico
+var trayIcon []byte
+
+//go:embed assets/icon_paused.ico
+var trayIconPaused []byte
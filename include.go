@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// includeFunc is the template function that inserts another prompt, as in
+// {{include "Only the result"}}
+const includeFunc = "include"
+
+// includeStub lets templates parse before the prompts they include are
+// known; executePrompt replaces it
+var includeStub = template.FuncMap{includeFunc: func(string) (string, error) { return "", nil }}
+
+// executePrompt renders the template of p with data. Prompts included
+// from it are looked up in prompts and rendered with the same data. chain
+// holds the titles being rendered, to stop circular includes.
+func executePrompt(p Prompt, prompts []Prompt, data PromptContext, chain []string) (string, error) {
+	if slices.Contains(chain, p.Title) {
+		return "", fmt.Errorf("circular include: %s", strings.Join(append(chain, p.Title), " → "))
+	}
+	chain = slices.Concat(chain, []string{p.Title})
+
+	tmpl, err := parsePrompt(p)
+	if err != nil {
+		return "", err
+	}
+	tmpl.Funcs(template.FuncMap{includeFunc: func(title string) (string, error) {
+		i := slices.IndexFunc(prompts, func(q Prompt) bool { return q.Title == title })
+		if i < 0 {
+			return "", fmt.Errorf("no prompt titled %q to include", title)
+		}
+		return executePrompt(prompts[i], prompts, data, chain)
+	}})
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
+
+// promptIncludes returns the titles that the template of p includes by a
+// literal title
+func promptIncludes(p Prompt) ([]string, error) {
+	tmpl, err := parsePrompt(p)
+	if err != nil {
+		return nil, err
+	}
+	var titles []string
+	if tmpl.Tree != nil {
+		walkIncludes(tmpl.Tree.Root, &titles)
+	}
+	return titles, nil
+}
+
+// walkIncludes adds the titles of the include calls under node to titles
+func walkIncludes(node parse.Node, titles *[]string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkIncludes(child, titles)
+		}
+	case *parse.ActionNode:
+		walkIncludes(n.Pipe, titles)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, titles)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, titles)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, titles)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkIncludes(cmd, titles)
+		}
+	case *parse.CommandNode:
+		if len(n.Args) == 2 {
+			ident, isIdent := n.Args[0].(*parse.IdentifierNode)
+			title, isString := n.Args[1].(*parse.StringNode)
+			if isIdent && isString && ident.Ident == includeFunc {
+				*titles = append(*titles, title.Text)
+			}
+		}
+		for _, arg := range n.Args {
+			walkIncludes(arg, titles)
+		}
+	}
+}
+
+func walkBranch(n *parse.BranchNode, titles *[]string) {
+	walkIncludes(n.Pipe, titles)
+	walkIncludes(n.List, titles)
+	walkIncludes(n.ElseList, titles)
+}
+
+// checkIncludes reports the first include of a prompt that doesn't exist
+// and the first circular include among prompts
+func checkIncludes(prompts []Prompt) error {
+	includes := make(map[string][]string, len(prompts))
+	for _, p := range prompts {
+		titles, err := promptIncludes(p)
+		if err != nil {
+			return err
+		}
+		for _, title := range titles {
+			if !slices.ContainsFunc(prompts, func(q Prompt) bool { return q.Title == title }) {
+				return fmt.Errorf("prompt %q includes %q, which doesn't exist", p.Title, title)
+			}
+		}
+		includes[p.Title] = titles
+	}
+
+	// Depth-first search, with the prompts on the current path in chain
+	done := make(map[string]bool)
+	var visit func(title string, chain []string) error
+	visit = func(title string, chain []string) error {
+		if slices.Contains(chain, title) {
+			return fmt.Errorf("circular include: %s", strings.Join(append(chain, title), " → "))
+		}
+		if done[title] {
+			return nil
+		}
+		chain = slices.Concat(chain, []string{title})
+		for _, next := range includes[title] {
+			if err := visit(next, chain); err != nil {
+				return err
+			}
+		}
+		done[title] = true
+		return nil
+	}
+	for _, p := range prompts {
+		if err := visit(p.Title, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	keyringService = "lingosnap"
+
+	// apiKeyInKeyring is stored in a secret setting, or as the only entry
+	// of Config.APIKeys, once the real value has moved to the OS keychain
+	apiKeyInKeyring = "keyring"
+
+	// keyringNone keeps the secrets in settings.json as plain text
+	keyringNone = "none"
+)
+
+// Keychain accounts of the secret settings. They don't depend on the
+// provider, so switching providers finds the same entries.
+const (
+	accountAPIKey       = "api_key"
+	accountAPIKeys      = "api_keys"
+	accountDeepLKey     = "deepl_key"
+	accountVisionAPIKey = "vision_api_key"
+)
+
+// apiKeyEnv names the environment variable holding each provider's key
+var apiKeyEnv = map[string]string{
+	providerGemini: "GEMINI_API_KEY",
+	providerOpenAI: "OPENAI_API_KEY",
+	providerDeepL:  "DEEPL_API_KEY",
+}
+
+// resolveAPIKey returns the key for the configured provider. The
+// environment variable wins over settings.json, which holds either the
+// key itself or a marker pointing at the OS keychain. For DeepL,
+// Config.DeepLKey comes before Config.APIKey.
+func resolveAPIKey(cfg *Config) (string, error) {
+	if key := os.Getenv(apiKeyEnv[cfg.Provider]); key != "" {
+		return key, nil
+	}
+	if cfg.Provider == providerDeepL && cfg.DeepLKey != "" {
+		return resolveSecret(cfg.DeepLKey, accountDeepLKey)
+	}
+	if cfg.APIKey != apiKeyInKeyring {
+		return cfg.APIKey, nil
+	}
+
+	key, err := keyring.Get(keyringService, accountAPIKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the API key from the keychain: %w", err)
+	}
+	return key, nil
+}
+
+// resolveAPIKeys returns the keys of Config.APIKeys, reading them from
+// the OS keychain when they have moved there
+func resolveAPIKeys(cfg *Config) ([]string, error) {
+	if !slices.Equal(cfg.APIKeys, []string{apiKeyInKeyring}) {
+		return cfg.APIKeys, nil
+	}
+	data, err := keyring.Get(keyringService, accountAPIKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read api_keys from the keychain: %w", err)
+	}
+	var keys []string
+	if err := json.Unmarshal([]byte(data), &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse api_keys from the keychain: %w", err)
+	}
+	return keys, nil
+}
+
+// resolveSecret returns the value of a secret setting, reading it from
+// the OS keychain account when it has moved there
+func resolveSecret(value, account string) (string, error) {
+	if value != apiKeyInKeyring {
+		return value, nil
+	}
+	secret, err := keyring.Get(keyringService, account)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s from the keychain: %w", account, err)
+	}
+	return secret, nil
+}
+
+// migrateAPIKey moves the plain-text api_key, api_keys, deepl_key and
+// vision_api_key from settings.json to the OS keychain. They stay in the
+// file when no keychain is available.
+func migrateAPIKey(cfg *Config) error {
+	if cfg.KeyringBackend == keyringNone {
+		return nil
+	}
+
+	secrets := []struct {
+		value   *string
+		account string
+	}{
+		{&cfg.APIKey, accountAPIKey},
+		{&cfg.DeepLKey, accountDeepLKey},
+		{&cfg.VisionAPIKey, accountVisionAPIKey},
+	}
+	var moved []string
+	for _, s := range secrets {
+		if *s.value == "" || *s.value == apiKeyInKeyring {
+			continue
+		}
+		if err := keyring.Set(keyringService, s.account, *s.value); err != nil {
+			slog.Warn(fmt.Sprintf("⚠️  Keychain unavailable, keeping the API keys in %s: %v", configFileName, err))
+			return saveMovedSecrets(cfg, moved)
+		}
+		*s.value = apiKeyInKeyring
+		moved = append(moved, s.account)
+	}
+	if len(cfg.APIKeys) > 0 && !slices.Equal(cfg.APIKeys, []string{apiKeyInKeyring}) {
+		data, err := json.Marshal(cfg.APIKeys)
+		if err != nil {
+			return err
+		}
+		if err := keyring.Set(keyringService, accountAPIKeys, string(data)); err != nil {
+			slog.Warn(fmt.Sprintf("⚠️  Keychain unavailable, keeping the API keys in %s: %v", configFileName, err))
+			return saveMovedSecrets(cfg, moved)
+		}
+		cfg.APIKeys = []string{apiKeyInKeyring}
+		moved = append(moved, accountAPIKeys)
+	}
+	return saveMovedSecrets(cfg, moved)
+}
+
+// saveMovedSecrets saves cfg once migrateAPIKey has moved the secrets
+// for the given accounts to the keychain
+func saveMovedSecrets(cfg *Config, moved []string) error {
+	if len(moved) == 0 {
+		return nil
+	}
+	if err := saveConfig(cfg); err != nil {
+		return err
+	}
+	log.Printf("✅ Moved %s to the keychain", strings.Join(moved, ", "))
+	return nil
+}
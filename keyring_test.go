@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestMigrateAPIKey(t *testing.T) {
+	useTempConfigDir(t)
+	keyring.MockInit()
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("DEEPL_API_KEY", "")
+
+	cfg := defaultConfig()
+	cfg.Provider = providerGemini
+	cfg.APIKey = "gemini-key"
+	cfg.APIKeys = []string{"pool-1", "pool-2"}
+	cfg.DeepLKey = "deepl-key:fx"
+	cfg.VisionAPIKey = "vision-key"
+	if err := migrateAPIKey(cfg); err != nil {
+		t.Fatalf("migrateAPIKey() failed: %v", err)
+	}
+
+	if cfg.APIKey != apiKeyInKeyring || cfg.DeepLKey != apiKeyInKeyring || cfg.VisionAPIKey != apiKeyInKeyring {
+		t.Errorf("secrets left in the config: %q, %q, %q", cfg.APIKey, cfg.DeepLKey, cfg.VisionAPIKey)
+	}
+	if !slices.Equal(cfg.APIKeys, []string{apiKeyInKeyring}) {
+		t.Errorf("api_keys left in the config: %q", cfg.APIKeys)
+	}
+	saved, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.APIKey != apiKeyInKeyring || saved.DeepLKey != apiKeyInKeyring || saved.VisionAPIKey != apiKeyInKeyring {
+		t.Errorf("secrets left in %s: %q, %q, %q", configFileName, saved.APIKey, saved.DeepLKey, saved.VisionAPIKey)
+	}
+
+	// Every provider finds its key after switching
+	for provider, want := range map[string]string{
+		providerGemini: "gemini-key",
+		providerOpenAI: "gemini-key",
+		providerDeepL:  "deepl-key:fx",
+	} {
+		cfg.Provider = provider
+		key, err := resolveAPIKey(cfg)
+		if err != nil || key != want {
+			t.Errorf("resolveAPIKey() with %s = %q, %v, want %q", provider, key, err, want)
+		}
+	}
+	keys, err := resolveAPIKeys(cfg)
+	if err != nil || !slices.Equal(keys, []string{"pool-1", "pool-2"}) {
+		t.Errorf("resolveAPIKeys() = %q, %v", keys, err)
+	}
+	vision, err := resolveSecret(cfg.VisionAPIKey, accountVisionAPIKey)
+	if err != nil || vision != "vision-key" {
+		t.Errorf("resolveSecret(vision_api_key) = %q, %v", vision, err)
+	}
+
+	// Migrating again changes nothing
+	if err := migrateAPIKey(cfg); err != nil {
+		t.Fatalf("second migrateAPIKey() failed: %v", err)
+	}
+	cfg.Provider = providerDeepL
+	if key, _ := resolveAPIKey(cfg); key != "deepl-key:fx" {
+		t.Errorf("second migration lost the DeepL key, got %q", key)
+	}
+}
+
+func TestMigrateAPIKeyWithoutKeychain(t *testing.T) {
+	useTempConfigDir(t)
+	keyring.MockInitWithError(errors.New("no keychain"))
+
+	cfg := defaultConfig()
+	cfg.APIKey = "plain"
+	cfg.DeepLKey = "deepl"
+	if err := migrateAPIKey(cfg); err != nil {
+		t.Fatalf("migrateAPIKey() failed: %v", err)
+	}
+	if cfg.APIKey != "plain" || cfg.DeepLKey != "deepl" {
+		t.Errorf("keys changed without a keychain: %q, %q", cfg.APIKey, cfg.DeepLKey)
+	}
+
+	keyring.MockInit()
+	cfg.KeyringBackend = keyringNone
+	if err := migrateAPIKey(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIKey != "plain" {
+		t.Errorf("keyring_backend none moved the key: %q", cfg.APIKey)
+	}
+}
@@ -0,0 +1,95 @@
+//go:build !headless
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"time"
+
+	"fyne.io/systray"
+)
+
+// keyCheckDelay is how long settings.json has to stay unchanged after the
+// key was edited before Config.AutoValidateKey tests it, so a half-typed
+// key saved along the way isn't reported
+const keyCheckDelay = 2 * time.Second
+
+// keyCheckInterval is how often settings.json is read for key changes
+const keyCheckInterval = 500 * time.Millisecond
+
+// testAPIKey tests the key in settings.json and shows the result. item,
+// if given, is disabled and shows that the test is running meanwhile.
+func (t *TranslatorApp) testAPIKey(item *systray.MenuItem) {
+	if item != nil {
+		item.Disable()
+		item.SetTitle("Testing API Key…")
+		defer func() {
+			item.SetTitle("Test API Key")
+			item.Enable()
+		}()
+	}
+
+	reply, err := testSavedConnection(context.Background())
+	if err != nil {
+		slog.Error(fmt.Sprintf("❌ Invalid — %v", err))
+		sendNotification("LingoSnap", "❌ Invalid — "+err.Error())
+		return
+	}
+	log.Printf("✅ Valid, the model replied %q", reply)
+	sendNotification("LingoSnap", "✅ Valid")
+	t.useSavedKey()
+}
+
+// useSavedKey switches the running app to the provider, model, endpoint
+// and keys in settings.json once they tested valid, so they apply without
+// a restart
+func (t *TranslatorApp) useSavedKey() {
+	saved, err := readSavedConfig()
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  %v", err))
+		return
+	}
+	err = t.updateConfig(func(cfg *Config) error {
+		cfg.Provider, cfg.Model = saved.Provider, saved.Model
+		cfg.BaseURL, cfg.CustomEndpoint = saved.BaseURL, saved.CustomEndpoint
+		cfg.APIKey, cfg.APIKeys, cfg.DeepLKey = saved.APIKey, saved.APIKeys, saved.DeepLKey
+		return nil
+	})
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  %v", err))
+	}
+}
+
+// keyFields returns the settings that decide whether the key works, as
+// they are in settings.json now
+func keyFields() string {
+	cfg, err := readSavedConfig()
+	if err != nil {
+		return ""
+	}
+	data, _ := json.Marshal([]any{cfg.Provider, cfg.BaseURL, cfg.CustomEndpoint, cfg.APIKey, cfg.APIKeys, cfg.DeepLKey})
+	return string(data)
+}
+
+// watchAPIKey tests the key each time it changes in settings.json, once
+// the file has stayed unchanged for keyCheckDelay
+func (t *TranslatorApp) watchAPIKey() {
+	last := keyFields()
+	var changedAt time.Time
+	for range time.Tick(keyCheckInterval) {
+		if current := keyFields(); current != last && current != "" {
+			last, changedAt = current, time.Now()
+			continue
+		}
+		if changedAt.IsZero() || time.Since(changedAt) < keyCheckDelay {
+			continue
+		}
+		changedAt = time.Time{}
+		log.Println("🔑 The API key changed, testing it...")
+		t.testAPIKey(nil)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minGuessLetters is how many letters guessLanguage needs before it
+// guesses the language of Latin-script text
+const minGuessLetters = 20
+
+// guessMargin is how much better the best trigram score must be than the
+// second best for guessLanguage to trust it
+const guessMargin = 1.3
+
+// scriptLanguages names the language of scripts that are used by mostly
+// one language. Scripts shared by several languages are told apart in
+// guessLanguage.
+var scriptLanguages = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Armenian, "hy"},
+	{unicode.Greek, "el"},
+	{unicode.Hangul, "ko"},
+	{unicode.Hebrew, "he"},
+	{unicode.Georgian, "ka"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Arabic, "ar"},
+}
+
+// trigramProfiles hold the most frequent trigrams of Latin-script
+// languages, most frequent first, with "_" standing for a word boundary
+var trigramProfiles = map[string][]string{
+	"en": {"_th", "the", "he_", "and", "_an", "nd_", "ing", "_of", "of_", "ng_", "_to", "to_", "ed_", "er_", "_in", "is_", "ion", "tio", "_a_", "hat"},
+	"de": {"en_", "er_", "_de", "der", "ie_", "_di", "die", "sch", "ich", "ch_", "ein", "_ei", "und", "_un", "nd_", "den", "che", "cht", "_zu", "ung"},
+	"fr": {"_de", "es_", "de_", "le_", "_le", "ent", "_la", "la_", "nt_", "ion", "les", "_pa", "_et", "et_", "ue_", "re_", "_qu", "que", "des", "_un"},
+	"es": {"_de", "de_", "os_", "_la", "la_", "el_", "_el", "es_", "_qu", "que", "ue_", "_en", "en_", "as_", "ent", "ión", "_lo", "ado", "los", "_co"},
+	"it": {"_di", "di_", "la_", "_la", "to_", "re_", "che", "_ch", "he_", "_de", "del", "zio", "ion", "_co", "ell", "lla", "ent", "one", "_il", "il_"},
+	"pt": {"_de", "de_", "os_", "do_", "_qu", "que", "ue_", "_a_", "ão_", "ção", "_do", "da_", "_da", "es_", "_co", "ent", "_e_", "com", "dos", "nte"},
+	"nl": {"en_", "_de", "de_", "an_", "het", "_he", "et_", "van", "_va", "_ee", "een", "cht", "_in", "ij_", "ing", "oor", "_ge", "aar", "nd_", "ver"},
+}
+
+// guessLanguage returns the ISO 639-1 code of the language text is most
+// likely in, without asking the model. It knows the languages with their
+// own script and the Latin-script languages of trigramProfiles, and
+// reports false when the text is too short or too mixed to tell.
+func guessLanguage(text string) (string, bool) {
+	counts := make(map[string]int)
+	letters, latin, cyrillic, kana, han := 0, 0, 0, 0, 0
+	ukrainian := false
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			ukrainian = ukrainian || strings.ContainsRune("іїєґІЇЄҐ", r)
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		default:
+			for _, s := range scriptLanguages {
+				if unicode.Is(s.table, r) {
+					counts[s.lang]++
+					break
+				}
+			}
+		}
+	}
+	if letters == 0 {
+		return "", false
+	}
+
+	// Japanese mixes kana with Han; Han alone is taken for Chinese
+	switch {
+	case kana > 0 && kana+han > letters/2:
+		return "ja", true
+	case han > letters/2:
+		return "zh", true
+	case cyrillic > letters/2 && ukrainian:
+		return "uk", true
+	case cyrillic > letters/2:
+		return "ru", true
+	}
+	for lang, n := range counts {
+		if n > letters/2 {
+			return lang, true
+		}
+	}
+	if latin <= letters/2 || latin < minGuessLetters {
+		return "", false
+	}
+	return guessLatinLanguage(text)
+}
+
+// guessLatinLanguage scores the trigrams of text against trigramProfiles,
+// more frequent trigrams weighing more
+func guessLatinLanguage(text string) (string, bool) {
+	trigrams := textTrigrams(text)
+	best, bestScore, secondScore := "", 0, 0
+	for lang, profile := range trigramProfiles {
+		score := 0
+		for rank, tri := range profile {
+			score += trigrams[tri] * (len(profile) - rank)
+		}
+		switch {
+		case score > bestScore:
+			best, bestScore, secondScore = lang, score, bestScore
+		case score > secondScore:
+			secondScore = score
+		}
+	}
+	if bestScore == 0 || float64(bestScore) < guessMargin*float64(secondScore) {
+		return "", false
+	}
+	return best, true
+}
+
+// textTrigrams counts the trigrams of the lowercased words of text, with
+// "_" marking the start and end of each word
+func textTrigrams(text string) map[string]int {
+	trigrams := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, word := range words {
+		runes := []rune("_" + word + "_")
+		for i := 0; i+3 <= len(runes); i++ {
+			trigrams[string(runes[i:i+3])]++
+		}
+	}
+	return trigrams
+}
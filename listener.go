@@ -0,0 +1,258 @@
+//go:build !headless
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	hook "github.com/robotn/gohook"
+)
+
+// modifierKeys don't move the cursor when pressed alone
+var modifierKeys = []string{"ctrl", "shift", "rshift", "alt", "ralt", "cmd", "rcmd"}
+
+// pasteSettleTime is how long the key presses of a paste may take to
+// arrive from the hook after it was made
+const pasteSettleTime = 500 * time.Millisecond
+
+// registerHotkey runs fn each time the combination is released. t.mu must
+// be held.
+func (t *TranslatorApp) registerHotkey(hotkey string, fn func()) {
+	keys := parseHotkey(hotkey)
+	for _, key := range keys {
+		if _, ok := hook.Keycode[key]; !ok {
+			slog.Warn(fmt.Sprintf("⚠️  Unknown key %q in hotkey %q, skipping", key, hotkey))
+			return
+		}
+	}
+	for _, key := range keys {
+		t.hotkeyKeys[hook.Keycode[key]] = true
+	}
+	hook.Register(hook.KeyUp, keys, func(e hook.Event) {
+		if t.coolingDown(hotkey) {
+			slog.Debug("hotkey ignored while cooling down", "hotkey", hotkey)
+			return
+		}
+		fn()
+	})
+}
+
+// runHotkeyListener registers the global hotkey for the selected prompt and
+// any prompt-specific hotkeys, then processes key events in the background
+func (t *TranslatorApp) runHotkeyListener() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.listening {
+		return
+	}
+	t.listening = true
+	t.hotkeyKeys = make(map[uint16]bool)
+
+	// t.mu is held, and a config is never changed once in use
+	cfg := t.config
+	hotkey := cfg.Hotkey
+	if err := validateHotkey(hotkey); err != nil {
+		showWarning(fmt.Sprintf("Hotkey %q can't be used: %v. Falling back to %s.", hotkey, err, defaultHotkey))
+		hotkey = defaultHotkey
+	}
+	t.registerHotkey(hotkey, func() { t.enqueueGlobalHotkey(hotkey, false) })
+
+	if copyHotkey := cfg.CopyHotkey; copyHotkey != "" {
+		if err := validateHotkey(copyHotkey); err != nil {
+			showWarning(fmt.Sprintf("Copy hotkey %q can't be used: %v", copyHotkey, err))
+		} else {
+			t.registerHotkey(copyHotkey, func() { t.enqueueGlobalHotkey(copyHotkey, true) })
+		}
+	}
+
+	for _, p := range cfg.Prompts {
+		if p.Hotkey == "" {
+			continue
+		}
+		if err := validateHotkey(p.Hotkey); err != nil {
+			showWarning(fmt.Sprintf("Hotkey for prompt %q can't be used: %v", p.Title, err))
+			continue
+		}
+		t.registerHotkey(p.Hotkey, func() {
+			log.Printf("▶ %s detected - processing selected text with %q...", p.Hotkey, p.Title)
+			t.queue.enqueue(p.Title, func(ctx context.Context) { t.processSelectedText(ctx, p, false) })
+		})
+	}
+
+	if undoHotkey := cfg.UndoHotkey; undoHotkey != "" {
+		if err := validateHotkey(undoHotkey); err != nil {
+			showWarning(fmt.Sprintf("Undo hotkey %q can't be used: %v", undoHotkey, err))
+		} else {
+			t.registerHotkey(undoHotkey, func() {
+				t.queue.enqueue("undo", func(context.Context) { t.undoLastPaste() })
+			})
+		}
+	}
+
+	if cleanupHotkey := cfg.AnnotateCleanupHotkey; cleanupHotkey != "" {
+		if err := validateHotkey(cleanupHotkey); err != nil {
+			showWarning(fmt.Sprintf("Annotation cleanup hotkey %q can't be used: %v", cleanupHotkey, err))
+		} else {
+			t.registerHotkey(cleanupHotkey, func() {
+				t.queue.enqueue("clean annotations", func(context.Context) { t.cleanAnnotations() })
+			})
+		}
+	}
+
+	if pauseHotkey := cfg.PauseHotkey; pauseHotkey != "" {
+		if err := validateHotkey(pauseHotkey); err != nil {
+			showWarning(fmt.Sprintf("Pause hotkey %q can't be used: %v", pauseHotkey, err))
+		} else {
+			t.registerHotkey(pauseHotkey, func() { go t.setEnabled(!t.enabled()) })
+		}
+	}
+
+	// Like the pause hotkey, this one works while translation is paused
+	if settingsHotkey := cfg.SettingsHotkey; settingsHotkey != "" {
+		if err := validateHotkey(settingsHotkey); err != nil {
+			showWarning(fmt.Sprintf("Settings hotkey %q can't be used: %v", settingsHotkey, err))
+		} else {
+			t.registerHotkey(settingsHotkey, openSettingsFile)
+		}
+	}
+
+	if ocrHotkey := cfg.OCRHotkey; ocrHotkey != "" {
+		if err := validateHotkey(ocrHotkey); err != nil {
+			showWarning(fmt.Sprintf("OCR hotkey %q can't be used: %v", ocrHotkey, err))
+		} else {
+			t.registerHotkey(ocrHotkey, t.onRegionHotkey)
+		}
+	}
+
+	hook.Register(hook.KeyDown, nil, t.forgetPaste)
+	hook.Register(hook.MouseDown, nil, t.forgetPaste)
+
+	s := hook.Start()
+	go func() { <-hook.Process(s) }()
+}
+
+// forgetPaste drops the last paste when a key is pressed or the mouse
+// clicked after it, since either may move the cursor away from the pasted
+// text and undo would select the wrong characters. Modifiers, the keys of
+// hotkeys and the paste's own key presses don't count.
+func (t *TranslatorApp) forgetPaste(e hook.Event) {
+	if e.Kind == hook.KeyDown {
+		modifier := slices.ContainsFunc(modifierKeys, func(key string) bool { return hook.Keycode[key] == e.Keycode })
+		if modifier {
+			return
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.lastPasted == "" || time.Since(t.pastedAt) < pasteSettleTime {
+		return
+	}
+	if e.Kind == hook.KeyDown && t.hotkeyKeys[e.Keycode] {
+		return
+	}
+	t.lastOriginal, t.lastPasted = "", ""
+	slog.Debug("last paste can no longer be undone", "keycode", e.Keycode)
+}
+
+// enqueueGlobalHotkey queues the translation of the selection for the
+// global or, with copyOnly, the copy hotkey. Without a matching app
+// profile and with Config.AutoSelectPrompt, the prompt is picked by the
+// language of the text.
+func (t *TranslatorApp) enqueueGlobalHotkey(hotkey string, copyOnly bool) {
+	prompt, fromProfile := t.globalHotkeyPrompt()
+	autoSelect := t.currentConfig().AutoSelectPrompt && !fromProfile
+	action := "processing selected text"
+	if copyOnly {
+		action = "copying the translation of the selected text"
+	}
+	if autoSelect {
+		log.Printf("▶ %s detected - %s with the prompt for its language...", hotkey, action)
+	} else {
+		log.Printf("▶ %s detected - %s with %q...", hotkey, action, prompt.Title)
+	}
+	t.queue.enqueue(prompt.Title, func(ctx context.Context) {
+		if autoSelect {
+			ctx = withAutoSelect(ctx)
+		}
+		t.processSelectedText(ctx, prompt, copyOnly)
+	})
+}
+
+// stopHotkeyListener unregisters all hotkeys
+func (t *TranslatorApp) stopHotkeyListener() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.listening {
+		return
+	}
+	t.listening = false
+	hook.End()
+}
+
+// restartHotkeyListener re-registers hotkeys after the config changed
+func (t *TranslatorApp) restartHotkeyListener() {
+	t.stopHotkeyListener()
+	t.runHotkeyListener()
+}
+
+// hotkeyListening reports whether hotkeys are currently registered
+func (t *TranslatorApp) hotkeyListening() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.listening
+}
+
+// keyNames maps hook keycodes back to the names used in hotkey strings,
+// preferring the shortest name when several share a code
+var keyNames = func() map[uint16]string {
+	names := make(map[uint16]string)
+	for name, code := range hook.Keycode {
+		// "+" separates keys, and shifted symbols duplicate their base key
+		if name == "+" || len(name) == 1 && strings.ContainsAny(name, `_{}|:"<>?`) {
+			continue
+		}
+		if old, ok := names[code]; !ok || len(name) < len(old) || len(name) == len(old) && name < old {
+			names[code] = name
+		}
+	}
+	return names
+}()
+
+// recordHotkey captures the next key combination pressed within timeout and
+// returns it as a hotkey string such as "ctrl+shift+t". Hotkeys must not be
+// listening while recording.
+func recordHotkey(timeout time.Duration) (string, error) {
+	events := hook.Start()
+	defer hook.End()
+
+	var pressed []string
+	deadline := time.After(timeout)
+	for {
+		select {
+		case e := <-events:
+			name, ok := keyNames[e.Keycode]
+			if !ok {
+				continue
+			}
+			switch e.Kind {
+			case hook.KeyDown, hook.KeyHold:
+				if !slices.Contains(pressed, name) {
+					pressed = append(pressed, name)
+				}
+			case hook.KeyUp:
+				if len(pressed) > 0 {
+					return strings.Join(pressed, "+"), nil
+				}
+			}
+		case <-deadline:
+			return "", fmt.Errorf("no keys pressed within %s", timeout)
+		}
+	}
+}
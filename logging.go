@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// logLevels are the values of Config.LogLevel
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// checkLogLevel rejects unknown log levels
+func checkLogLevel(cfg *Config) error {
+	if _, ok := logLevels[cfg.LogLevel]; !ok && cfg.LogLevel != "" {
+		return fmt.Errorf(`log_level must be "debug", "info", "warn" or "error", got %q`, cfg.LogLevel)
+	}
+	return nil
+}
+
+// setupLogging sends the log through slog at Config.LogLevel: readable
+// lines on stderr and, with Config.LogFile, JSON lines in that file.
+// Errors and warnings are logged with slog.Error and slog.Warn; the log
+// package keeps working for the info messages. The returned function
+// closes the file.
+func setupLogging(cfg *Config) (func(), error) {
+	level := logLevels[cfg.LogLevel]
+	handlers := []slog.Handler{newConsoleHandler(os.Stderr, level)}
+	closeFile := func() {}
+	if cfg.LogFile != "" {
+		f, err := os.OpenFile(cfg.LogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		handlers = append(handlers, slog.NewJSONHandler(f, &slog.HandlerOptions{Level: level}))
+		closeFile = func() { f.Close() }
+	}
+
+	logger := slog.New(fanoutHandler(handlers))
+	slog.SetDefault(logger)
+	log.SetFlags(0)
+	log.SetOutput(logBridge{logger})
+	return closeFile, nil
+}
+
+// fatal logs v at error level, which every log_level shows, and exits
+// like log.Fatal does
+func fatal(v ...any) {
+	slog.Error(fmt.Sprint(v...))
+	os.Exit(1)
+}
+
+// fatalf is fatal with a format
+func fatalf(format string, v ...any) {
+	fatal(fmt.Sprintf(format, v...))
+}
+
+// logBridge turns the lines written by the log package into info records
+type logBridge struct {
+	logger *slog.Logger
+}
+
+func (b logBridge) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	b.logger.Info(msg)
+	return len(p), nil
+}
+
+// consoleHandler writes records the way the log package does, followed by
+// their attributes as key=value
+type consoleHandler struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	level  slog.Level
+	attrs  string // preformatted attributes added by WithAttrs
+	prefix string // group names added by WithGroup, joined by dots
+}
+
+func newConsoleHandler(out io.Writer, level slog.Level) *consoleHandler {
+	return &consoleHandler{mu: &sync.Mutex{}, out: out, level: level}
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var line strings.Builder
+	line.WriteString(r.Time.Format("2006/01/02 15:04:05 "))
+	line.WriteString(r.Message)
+	line.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&line, h.prefix, a)
+		return true
+	})
+	line.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.out, line.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var s strings.Builder
+	for _, a := range attrs {
+		writeAttr(&s, h.prefix, a)
+	}
+	clone := *h
+	clone.attrs += s.String()
+	return &clone
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.prefix += name + "."
+	return &clone
+}
+
+// writeAttr writes a as " key=value", flattening groups into dotted keys
+func writeAttr(s *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		for _, g := range a.Value.Group() {
+			writeAttr(s, prefix+a.Key+".", g)
+		}
+		return
+	}
+	fmt.Fprintf(s, " %s%s=%v", prefix, a.Key, a.Value)
+}
+
+// fanoutHandler passes records to every handler that wants them
+type fanoutHandler []slog.Handler
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupLoggingLevels(t *testing.T) {
+	previous := slog.Default()
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	})
+
+	for level, want := range map[string][]string{
+		"debug": {"debug line", "info line", "warn line", "error line"},
+		"info":  {"info line", "warn line", "error line"},
+		"warn":  {"warn line", "error line"},
+		"error": {"error line"},
+	} {
+		cfg := defaultConfig()
+		cfg.LogLevel = level
+		cfg.LogFile = filepath.Join(t.TempDir(), "lingosnap.log")
+		closeFile, err := setupLogging(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slog.Debug("debug line")
+		log.Printf("info line")
+		slog.Warn("warn line")
+		slog.Error("error line")
+		closeFile()
+
+		data, err := os.ReadFile(cfg.LogFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != len(want) {
+			t.Errorf("log_level %s logged %d lines, want %d: %s", level, len(lines), len(want), data)
+			continue
+		}
+		for i, msg := range want {
+			if !strings.Contains(lines[i], `"msg":"`+msg+`"`) {
+				t.Errorf("log_level %s line %d = %s, want %q", level, i, lines[i], msg)
+			}
+		}
+	}
+}
@@ -1,157 +1,754 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"image"
+	"io"
 	"log"
+	"log/slog"
 	"os"
-	"runtime"
-	"strings"
+	"slices"
+	"sync"
 	"time"
 
-	"github.com/atotto/clipboard"
-	"github.com/go-vgo/robotgo"
 	"github.com/joho/godotenv"
-	hook "github.com/robotn/gohook"
-	"google.golang.org/genai"
 )
 
 func main() {
+	cliMode := flag.Bool("cli", false, "translate text from -text or stdin, print the result and exit")
+	cliText := flag.String("text", "", "text to translate in -cli mode (default: read stdin)")
+	cliPrompt := flag.String("prompt", "", "title of the prompt to use in -cli mode (default: the selected prompt)")
+	filePath := flag.String("translate-file", "", "translate an .srt, .txt or .md file with the selected prompt and exit")
+	batchPath := flag.String("batch-file", "", "translate each paragraph of a text file independently with the selected prompt and exit")
+	csvPath := flag.String("translate-csv", "", "translate the -csv-columns columns of a CSV file with the selected prompt and exit")
+	csvColumns := flag.String("csv-columns", "", `with -translate-csv, the columns to translate, each optionally with a target language, e.g. "name:de, description"`)
+	serveAddr := flag.String("serve", "", "serve the HTTP API on this address, e.g. :8080")
+	exportPath := flag.String("export-prompts", "", "write your prompts to this JSON file and exit")
+	importPath := flag.String("import-prompts", "", "add the prompts from this JSON file and exit")
+	importMode := flag.String("import-mode", "merge", `how -import-prompts and -import-bundle treat the existing setup: "merge" or "replace"`)
+	shareTitle := flag.String("share-prompt", "", "print a share code for the prompt with this title and exit")
+	qrPath := flag.String("qr", "", "with -share-prompt, also write the code as a QR code PNG to this file (needs qrencode)")
+	importShare := flag.String("import-share", "", `add the prompt of this share code, or "-" to read it from stdin, and exit`)
+	tmxPath := flag.String("import-tmx", "", "add the terms of this TMX translation memory to the glossary and exit")
+	tmxSource := flag.String("tmx-source", "", "with -import-tmx, the source language (default: the srclang of the file)")
+	tmxTarget := flag.String("tmx-target", defaultTargetLang, "with -import-tmx, the target language")
+	tmxDomain := flag.String("tmx-domain", "", `with -import-tmx, only import units with this "x-domain" property`)
+	exportBundlePath := flag.String("export-bundle", "", "write the settings, history and plugins to this ZIP file and exit")
+	importBundlePath := flag.String("import-bundle", "", "apply the settings, history and plugins of this ZIP file and exit")
+	movePromptTitle := flag.String("move-prompt", "", "move the prompt with this title to the position given by -to and exit")
+	moveTo := flag.Int("to", 1, "1-based position for -move-prompt; the default prompt always stays first")
+	listBackupsFlag := flag.Bool("list-backups", false, "print the settings backups, newest first, and exit")
+	restoreName := flag.String("restore-backup", "", "replace settings.json with the backup of this name and exit")
+	pinTitle := flag.String("pin-prompt", "", "pin the prompt with this title to the top of the tray menu and exit")
+	unpinTitle := flag.String("unpin-prompt", "", "unpin the prompt with this title and exit")
+	duplicateTitle := flag.String("duplicate-prompt", "", "add a copy of the prompt with this title right after it and exit")
+	versionsTitle := flag.String("prompt-history", "", "print the earlier versions of the prompt with this title, compared with its current text, and exit")
+	historyPath := flag.String("export-history", "", "write the translation history to this file and exit")
+	historyFormat := flag.String("history-format", historyCSV, `format for -export-history: "csv" or "anki"`)
+	flaggedOnly := flag.Bool("flagged-only", false, "with -export-history, only export translations the model stopped early, e.g. at MAX_TOKENS or for SAFETY")
+	reportQuery := flag.String("report-history", "", "report the newest history entry containing this text as a bad translation and exit")
+	reportProblem := flag.String("problem", "", "what is wrong with the translation, for -report-history")
+	reportText := flag.Bool("include-text", false, "with -report-history, include the original, translation and prompt in the report")
+	statsRange := flag.String("stats", "", `print the most used language pairs over "7d", "30d" or "all" and exit`)
+	statsModel := flag.String("stats-model", "", "with -stats, only show the latencies of this model")
+	statsPath := flag.String("export-stats", "", "with -stats, also write the language pairs to this JSON file")
+	listModels := flag.Bool("list-models", false, "print the models offered by the configured provider and exit")
+	refreshModels := flag.Bool("refresh-models", false, "with -list-models, fetch the list again even if it was fetched recently")
+	testConn := flag.Bool("test-connection", false, `send "Say OK" to the configured model to check the endpoint and key, and exit`)
+	exportKey := flag.Bool("export-key", false, "print the API key of the configured provider, e.g. to recover it from the keychain, and exit")
+	flag.Parse()
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
-	
-	if os.Getenv("GEMINI_API_KEY") == "" {
-		log.Fatal("GEMINI_API_KEY environment variable is required")
-	}
 
-	log.Println("✅ Text Translator is running...")
-	log.Println("   Usage: Select text, then press and release Right Shift")
-	log.Println("   The text will be automatically translated and pasted")
-	if runtime.GOOS == "darwin" {
-		log.Println("   Note: On macOS, you may need to grant accessibility permissions")
+	// Backups are handled before loading so a broken settings.json can
+	// be restored
+	if *listBackupsFlag {
+		dir, err := backupsDir()
+		if err != nil {
+			fatal(err)
+		}
+		backups, err := listBackups(dir)
+		if err != nil {
+			fatal(err)
+		}
+		for _, b := range backups {
+			fmt.Printf("%s  %s\n", b.SavedAt.Local().Format("2006-01-02 15:04:05"), b.Name)
+		}
+		return
+	}
+	if *restoreName != "" {
+		if err := restoreBackup(*restoreName); err != nil {
+			fatal(err)
+		}
+		log.Printf("✅ Restored %s from %s", configFileName, *restoreName)
+		return
 	}
-	log.Println("   Press Ctrl+C to exit")
 
-	// Register Right Shift key release event
-	hook.Register(hook.KeyUp, []string{"rshift"}, func(e hook.Event) {
-		log.Println("▶ Right Shift detected - processing selected text...")
-		go processSelectedText()
-	})
+	config, err := loadConfig()
+	if err != nil {
+		fatalf("Failed to load config: %v", err)
+	}
+	closeLog, err := setupLogging(config)
+	if err != nil {
+		fatal(err)
+	}
+	defer closeLog()
 
-	s := hook.Start()
-	<-hook.Process(s)
-}
+	if *exportKey {
+		key, err := resolveAPIKey(config)
+		if err != nil {
+			fatal(err)
+		}
+		if key == "" {
+			fatalf("No API key is configured for %s", config.Provider)
+		}
+		fmt.Println(key)
+		return
+	}
+	if *exportPath != "" {
+		if err := exportPrompts(*exportPath, config.Prompts); err != nil {
+			fatal(err)
+		}
+		log.Printf("✅ Exported %d prompts to %s", len(config.Prompts), *exportPath)
+		return
+	}
+	if *importPath != "" {
+		if *importMode != "merge" && *importMode != "replace" {
+			fatalf("Invalid -import-mode %q, use \"merge\" or \"replace\"", *importMode)
+		}
+		if err := importPrompts(config, *importPath, *importMode == "replace"); err != nil {
+			fatal(err)
+		}
+		log.Printf("✅ Imported prompts from %s, %d prompts configured", *importPath, len(config.Prompts))
+		return
+	}
+	if *shareTitle != "" {
+		i := slices.IndexFunc(config.Prompts, func(p Prompt) bool { return p.Title == *shareTitle })
+		if i < 0 {
+			fatalf("No prompt titled %q", *shareTitle)
+		}
+		code := shareCode(config.Prompts[i])
+		fmt.Println(code)
+		if *qrPath != "" {
+			if err := writeQRCode(code, *qrPath); err != nil {
+				fatal(err)
+			}
+			log.Printf("✅ QR code written to %s", *qrPath)
+		}
+		return
+	}
+	if *importShare != "" {
+		code := *importShare
+		if code == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fatalf("Failed to read stdin: %v", err)
+			}
+			code = string(data)
+		}
+		p, err := importShareCode(config, code)
+		if err != nil {
+			fatal(err)
+		}
+		log.Printf("✅ Imported %q", p.Title)
+		return
+	}
+	if *tmxPath != "" {
+		n, err := importTMX(config, *tmxPath, *tmxSource, *tmxTarget, *tmxDomain)
+		if err != nil {
+			fatal(err)
+		}
+		log.Printf("✅ Added %d terms from %s, %d in the glossary", n, *tmxPath, len(config.Glossary))
+		return
+	}
+	if *exportBundlePath != "" || *importBundlePath != "" {
+		if *importMode != "merge" && *importMode != "replace" {
+			fatalf("Invalid -import-mode %q, use \"merge\" or \"replace\"", *importMode)
+		}
+		passphrase, err := readPassphrase()
+		if err != nil {
+			fatal(err)
+		}
+		history, err := openHistory(config.MaxHistory)
+		if err != nil {
+			fatalf("Failed to open history: %v", err)
+		}
+		if *exportBundlePath != "" {
+			err = exportBundle(config, history, *exportBundlePath, passphrase)
+		} else {
+			var plugins []string
+			if plugins, err = bundlePlugins(*importBundlePath); err == nil {
+				install := len(plugins) > 0 && confirmPluginsOnTerminal(plugins)
+				err = importBundle(config, history, *importBundlePath, passphrase, *importMode == "replace", install)
+			}
+		}
+		history.Close()
+		if err != nil {
+			fatal(err)
+		}
+		if *exportBundlePath != "" {
+			log.Printf("✅ Exported the settings, history and plugins to %s", *exportBundlePath)
+		} else {
+			log.Printf("✅ Imported %s", *importBundlePath)
+		}
+		return
+	}
+	if *historyPath != "" {
+		history, err := openHistory(config.MaxHistory)
+		if err != nil {
+			fatalf("Failed to open history: %v", err)
+		}
+		n, err := exportHistory(history, *historyPath, *historyFormat, config.AnkiMaxLen, *flaggedOnly)
+		history.Close()
+		if err != nil {
+			fatal(err)
+		}
+		log.Printf("✅ Exported %d history entries to %s", n, *historyPath)
+		return
+	}
+	if *reportQuery != "" {
+		if err := reportHistoryEntry(config, *reportQuery, *reportProblem, *reportText); err != nil {
+			fatal(err)
+		}
+		log.Println("✅ Feedback sent, thank you")
+		return
+	}
+	if *statsRange != "" {
+		since, err := statsSince(*statsRange, time.Now())
+		if err != nil {
+			fatal(err)
+		}
+		history, err := openHistory(config.MaxHistory)
+		if err != nil {
+			fatalf("Failed to open history: %v", err)
+		}
+		pairs, err := history.LanguagePairs(since, statsTopPairs)
+		if err != nil {
+			fatal(err)
+		}
+		latencies, err := history.Latencies(since, *statsModel)
+		history.Close()
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Println(formatPairChart(pairs))
+		fmt.Println("\nLatency:\n" + formatLatencyChart(latencies))
+		if *statsPath != "" {
+			if err := exportStats(*statsPath, *statsRange, since, pairs, latencies); err != nil {
+				fatal(err)
+			}
+			log.Printf("✅ Statistics written to %s", *statsPath)
+		}
+		return
+	}
+	if *movePromptTitle != "" {
+		if err := movePrompt(config, *movePromptTitle, *moveTo); err != nil {
+			fatal(err)
+		}
+		log.Printf("✅ Moved %q to position %d", *movePromptTitle, *moveTo)
+		return
+	}
+	if *pinTitle != "" || *unpinTitle != "" {
+		title, pinned, done := *pinTitle, true, "Pinned"
+		if title == "" {
+			title, pinned, done = *unpinTitle, false, "Unpinned"
+		}
+		if err := setPromptPinned(config, title, pinned); err != nil {
+			fatal(err)
+		}
+		log.Printf("✅ %s %q", done, title)
+		return
+	}
+	if *duplicateTitle != "" {
+		title, err := duplicatePrompt(config, *duplicateTitle)
+		if err != nil {
+			fatal(err)
+		}
+		log.Printf("✅ Added %q; rename and edit it in %s", title, configFileName)
+		return
+	}
+	if *versionsTitle != "" {
+		i := slices.IndexFunc(config.Prompts, func(p Prompt) bool { return p.Title == *versionsTitle })
+		if i < 0 {
+			fatalf("No prompt titled %q", *versionsTitle)
+		}
+		versions := promptVersions(config, *versionsTitle)
+		if len(versions) == 0 {
+			log.Printf("No earlier versions of %q", *versionsTitle)
+			return
+		}
+		color := isTerminal(os.Stdout)
+		for _, v := range versions {
+			ops := wordDiff(v.Text, config.Prompts[i].Text)
+			diff := formatDiff(ops)
+			if color {
+				diff = colorDiff(ops)
+			}
+			fmt.Printf("%s\n%s\n\n", v.SavedAt.Local().Format("2006-01-02 15:04:05"), diff)
+		}
+		return
+	}
 
-func processSelectedText() {
-	// Save current clipboard content before processing
-	previousClipboard, err := clipboard.ReadAll()
+	shutdownTracing, err := initTracing(config)
 	if err != nil {
-		log.Printf("⚠️  Failed to read current clipboard: %v", err)
-		// Continue anyway - we'll just not restore it
-		previousClipboard = ""
+		fatal(err)
+	}
+	defer shutdownTracing(context.Background())
+
+	usage := &usageTracker{}
+	app := &TranslatorApp{
+		config:  config,
+		usage:   usage,
+		cache:   newTranslationCache(config.CacheSize, config.CacheTTL.Std()),
+		started: time.Now(),
+	}
+	if err := app.resetClient(config); err != nil {
+		fatal(err)
 	}
 
-	// Copy selected text to clipboard
-	copyToClipboard()
-	time.Sleep(200 * time.Millisecond)
+	if *testConn {
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout(config, Prompt{}))
+		defer cancel()
+		reply, err := testConnection(ctx, app.translator)
+		if err != nil {
+			fatalf("❌ Connection failed: %v", err)
+		}
+		log.Printf("✅ Connected to %s, the model replied %q", config.Model, reply)
+		return
+	}
 
-	selectedText, err := clipboard.ReadAll()
+	history, err := openHistory(config.MaxHistory)
 	if err != nil {
-		log.Printf("❌ Failed to read clipboard: %v", err)
-		restoreClipboard(previousClipboard)
+		fatalf("Failed to open history: %v", err)
+	}
+	defer history.Close()
+	usage.store = history
+	app.history = history
+
+	if *listModels {
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout(config, Prompt{}))
+		defer cancel()
+		for _, model := range app.availableModels(ctx, *refreshModels) {
+			if model == config.Model {
+				model += " (selected)"
+			}
+			fmt.Println(model)
+		}
 		return
 	}
 
-	if strings.TrimSpace(selectedText) == "" {
-		log.Println("⚠️  No text selected")
-		restoreClipboard(previousClipboard)
+	if *filePath != "" {
+		if err := app.translateFile(*filePath); err != nil {
+			fatal(err)
+		}
 		return
 	}
 
-	log.Printf("   Original: %s", truncateText(selectedText, 50))
+	if *batchPath != "" {
+		out, err := app.translateBatchFile(*batchPath, app.selectedPrompt(), func(done, total int) {
+			log.Printf("   Paragraph %d/%d", done, total)
+		})
+		if err != nil {
+			fatal(err)
+		}
+		log.Printf("✅ Translation written to %s", out)
+		return
+	}
 
-	correctedText, err := translateWithGemini(selectedText)
-	if err != nil {
-		log.Printf("❌ Translation failed: %v", err)
-		restoreClipboard(previousClipboard)
+	if *csvPath != "" {
+		columns, err := parseCSVColumns(*csvColumns)
+		if err != nil {
+			fatal(err)
+		}
+		out, err := app.translateCSVFile(*csvPath, app.selectedPrompt(), columns, func(done, total int) {
+			log.Printf("   Cell %d/%d", done, total)
+		})
+		if err != nil {
+			fatal(err)
+		}
+		log.Printf("✅ Translation written to %s", out)
 		return
 	}
 
-	// Put corrected text in clipboard and paste it
-	if err := clipboard.WriteAll(correctedText); err != nil {
-		log.Printf("❌ Failed to write to clipboard: %v", err)
-		restoreClipboard(previousClipboard)
+	if *cliMode {
+		if err := app.runCLI(*cliText, *cliPrompt); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 		return
 	}
 
-	time.Sleep(100 * time.Millisecond)
-	pasteFromClipboard()
+	if config.MetricsAddr != "" {
+		go serveMetrics(config.MetricsAddr)
+	}
+	if config.WebsocketAddr != "" {
+		if config.WebsocketToken == "" {
+			fatal("websocket_token must be set in settings.json to use websocket_addr")
+		}
+		go app.serveWebsocket(config.WebsocketAddr)
+	}
+
+	if *serveAddr != "" {
+		if config.ServerToken == "" {
+			fatal("server_token must be set in settings.json to use -serve")
+		}
+		srv := app.newServer(*serveAddr)
+		log.Printf("🌐 Serving the API on %s", *serveAddr)
+		if !desktopSupported {
+			fatal(srv.ListenAndServe())
+		}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil {
+				fatalf("API server failed: %v", err)
+			}
+		}()
+	}
+
+	app.runDesktop()
+}
+
+// TranslatorApp holds the state shared by hotkey-triggered translations
+type TranslatorApp struct {
+	history *HistoryStore
+	usage   *usageTracker
+	cache   *translationCache
+	queue   *jobQueue // hotkey translations, desktop only
+	started time.Time
 
-	log.Printf("   Corrected: %s", truncateText(correctedText, 50))
-	log.Println("✅ Text translated and pasted successfully")
+	mu              sync.Mutex // guards the fields below
+	config          *Config
+	translator      Translator
+	limiter         *rateLimiter             // nil when requests aren't limited
+	onRateLimitWait func(wait time.Duration) // updates the tray, desktop only
+	listening       bool
+	regionStart     *image.Point        // first corner of the OCR region being captured
+	lastOriginal    string              // text replaced by the last paste, for undo
+	lastPasted      string              // text inserted by the last paste
+	pastedAt        time.Time           // when the last paste was made
+	hotkeyKeys      map[uint16]bool     // keycodes of the registered hotkeys, desktop only
+	onEnabledChange func(enabled bool)  // updates the tray, desktop only
+	openWindows     int                 // translation windows open now
+	budgetWarned    bool                // the 80% budget warning was shown
+	budgetOverride  bool                // translating past the budget was confirmed
+	onSpendChange   func(spend float64) // updates the tray, desktop only
 
-	// Restore original clipboard content after a short delay
-	time.Sleep(100 * time.Millisecond)
-	restoreClipboard(previousClipboard)
+	// Prompt title, input and output of the last hotkey translation, for
+	// similarTranslation
+	lastPrompt, lastInput, lastOutput string
+
+	// promptUndo holds the edits of each prompt this session, by title
+	promptUndo map[string]*undoStack
+
+	// lastTriggers holds when each hotkey last fired, by normalized
+	// hotkey, for the cooldown
+	lastTriggers map[string]time.Time
+
+	// onPromptRename updates the tray title of the prompt at an index,
+	// desktop only
+	onPromptRename func(i int, title string)
+
+	// shutdownWg counts the hotkey translations running now, inFlight of
+	// them, so quitting can wait for them. No new ones start once
+	// shuttingDown is set.
+	shutdownWg   sync.WaitGroup
+	inFlight     int
+	shuttingDown bool
+}
+
+// currentConfig returns the config in use. Changes swap in a new *Config
+// through updateConfig instead of editing it, so a translation that takes
+// it once reads consistent settings throughout. Don't modify the result.
+func (t *TranslatorApp) currentConfig() *Config {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.config
+}
+
+// updateConfig applies change to a copy of the config and swaps the copy
+// in, leaving the config as it was when change fails. change runs under
+// t.mu and is usually where the copy gets saved.
+func (t *TranslatorApp) updateConfig(change func(cfg *Config) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.swapConfig(change)
 }
 
-func translateWithGemini(text string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
+// swapConfig is updateConfig for callers that hold t.mu. The translator
+// is rebuilt when the change touches its clientSettings, e.g. a new key or
+// model.
+func (t *TranslatorApp) swapConfig(change func(cfg *Config) error) error {
+	next, err := t.config.clone()
+	if err != nil {
+		return err
+	}
+	if err := change(next); err != nil {
+		return err
+	}
+	if clientSettings(next) != clientSettings(t.config) {
+		if err := t.resetClient(next); err != nil {
+			slog.Warn(fmt.Sprintf("⚠️  %v, keeping the previous translator", err))
+		}
+	}
+	t.config = next
+	return nil
+}
 
-	// Create genai client - gets API key from GEMINI_API_KEY env var
-	client, err := genai.NewClient(ctx, nil)
+// resetClient replaces the translator and its clients with ones built from
+// cfg. The rate limiter is kept, with the requests it has seen, unless its
+// limits change. t.mu must be held once the app is running.
+func (t *TranslatorApp) resetClient(cfg *Config) error {
+	translator, err := newTranslator(cfg, t.usage)
 	if err != nil {
-		return "", fmt.Errorf("failed to create client: %w", err)
+		return err
+	}
+	// Settings outside clientSettings apply without another rebuild
+	if gemini, ok := translator.(*GeminiTranslator); ok {
+		gemini.config = t.currentConfig
 	}
+	t.translator = translator
+	slog.Debug("translator ready", "provider", cfg.Provider, "model", cfg.Model)
 
-	prompt := fmt.Sprintf(`Translate this text to English and fix any grammar or spelling errors. 
-If the text is already in English, just correct any errors. 
-If it's in Armenian (including transliterated Armenian), translate to English.
-Return only the corrected/translated text without any additional comments or explanations:
+	// The limits guard the Gemini free tier quota
+	if cfg.Provider != providerGemini {
+		t.limiter = nil
+		return nil
+	}
+	if t.limiter == nil || t.limiter.maxRequests != cfg.MaxRequestsPerMin || t.limiter.maxTokens != cfg.MaxTokensPerMin {
+		t.limiter = newRateLimiter(cfg.MaxRequestsPerMin, cfg.MaxTokensPerMin)
+		t.limiter.setOnWait(t.onRateLimitWait)
+	}
+	return nil
+}
 
-%s`, text)
+// backend returns the translator and rate limiter in use
+func (t *TranslatorApp) backend() (Translator, *rateLimiter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.translator, t.limiter
+}
 
-	result, err := client.Models.GenerateContent(
-		ctx,
-		"gemini-2.0-flash",
-		genai.Text(prompt),
-		nil,
-	)
+// translateOnce renders the prompt, translates text and records the result
+// in the history. Repeated texts are answered from the cache.
+func (t *TranslatorApp) translateOnce(ctx context.Context, prompt Prompt, text string) (string, error) {
+	cfg := t.currentConfig()
+	input := t.applyPreProcess(text)
+	promptText, data, err := t.renderPromptContext(ctx, prompt, input)
 	if err != nil {
-		return "", fmt.Errorf("generation failed: %w", err)
+		return "", err
+	}
+	settings := cacheSettings(cfg)
+	key := cacheKey(settings, promptText, data.TargetLang, text)
+	if s, ok := surroundingFrom(ctx); ok {
+		key = cacheKey(settings, promptText, data.TargetLang, s.before+"\x00"+text+"\x00"+s.after)
+	}
+	// The cache only keeps the primary translation, and a dry run has to
+	// reach the request
+	_, wantAlternatives := alternativesFrom(ctx)
+	if _, dryRun := dryRunFrom(ctx); !wantAlternatives && !dryRun {
+		if cached, ok := t.cache.get(key); ok {
+			t.usage.addCacheLookup(true)
+			cacheHitsTotal.Inc()
+			log.Println("   Using cached translation")
+			return cached, nil
+		}
+		t.usage.addCacheLookup(false)
+		slog.Debug("translation not cached", "prompt_title", prompt.Title)
 	}
 
-	return strings.TrimSpace(result.Text()), nil
+	ctx, span := startSpan(ctx, "translate", cfg.Model, prompt.Title)
+	start := time.Now()
+	translated, err := t.translateUncached(ctx, prompt, text, input, promptText, data)
+	span.end(err)
+	if errors.Is(err, errDryRun) {
+		return "", err
+	}
+	latency := time.Since(start)
+	translationDuration.Observe(latency.Seconds())
+	entry := slog.Group("translation", "model", cfg.Model, "prompt_title", prompt.Title, "latency_ms", latency.Milliseconds())
+	if err != nil {
+		translationsTotal.WithLabelValues(cfg.Model, prompt.Title, "error").Inc()
+		slog.Warn("translation failed", entry, "error", err)
+		return "", err
+	}
+	slog.Info("translated", entry)
+	translationsTotal.WithLabelValues(cfg.Model, prompt.Title, "ok").Inc()
+	t.cache.put(key, translated)
+	return translated, nil
 }
 
-// copyToClipboard handles OS-specific copy shortcuts
-func copyToClipboard() {
-	if runtime.GOOS == "darwin" {
-		robotgo.KeyTap("c", "cmd") // macOS uses Cmd+C
-	} else {
-		robotgo.KeyTap("c", "ctrl") // Windows/Linux use Ctrl+C
+// translateUncached does the work of translateOnce after a cache miss,
+// sending input, the pre-processed text, with the prompt rendered from
+// data
+func (t *TranslatorApp) translateUncached(ctx context.Context, prompt Prompt, text, input, promptText string, data PromptContext) (string, error) {
+	cfg := t.currentConfig()
+	start := time.Now()
+
+	var codeSpans []string
+	if cfg.PreserveMarkdown {
+		input, codeSpans = extractCode(input)
+		promptText += preserveMarkdownInstruction
+	}
+	if cfg.OutputFormat == outputJSON {
+		promptText += jsonOutputInstruction
+	}
+	if cfg.TransliterateArmenian {
+		if converted := transliterateArmenian(input, transliterationTable(cfg.TransliterationTable)); converted != input {
+			input = converted
+			promptText += transliterationInstruction
+		}
+	}
+	if s, ok := surroundingFrom(ctx); ok {
+		input = s.before + "<translate>" + input + "</translate>" + s.after
+		promptText += surroundingInstruction
+	}
+	if len(codeSpans) > 0 || cfg.OutputFormat == outputJSON || len(cfg.PostProcessRules) > 0 {
+		ctx = withoutChunkSink(ctx)
+	}
+
+	ctx, langs := withLanguages(ctx, data.SourceLang, data.TargetLang)
+	ctx, flags := withTranslationFlags(ctx)
+	translated, err := t.translate(ctx, promptText, input)
+	latency := time.Since(start)
+	if err != nil {
+		return "", fmt.Errorf("translation failed: %w", err)
+	}
+	if flags.reason != "" {
+		slog.Warn(fmt.Sprintf("⚠️  The model stopped early (%s), the translation may be incomplete", flags.reason))
+	}
+	sourceLang := cmp.Or(data.SourceLang, langs.detected)
+	if cfg.OutputFormat == outputJSON {
+		var detected string
+		translated, detected = t.unwrapJSONResult(translated, codeSpans)
+		if sourceLang == "" {
+			sourceLang = detected
+		}
+	}
+	translated = t.applyPostProcess(restoreCode(translated, codeSpans))
+	var alternatives []string
+	if alts, ok := alternativesFrom(ctx); ok {
+		for i, alt := range alts.texts {
+			if cfg.OutputFormat == outputJSON {
+				if result, err := parseTranslationResult(alt); err == nil {
+					alt = result.Translated
+				}
+			}
+			alts.texts[i] = t.applyPostProcess(restoreCode(alt, codeSpans))
+		}
+		alternatives = alts.texts
 	}
-}
 
-// pasteFromClipboard handles OS-specific paste shortcuts  
-func pasteFromClipboard() {
-	if runtime.GOOS == "darwin" {
-		robotgo.KeyTap("v", "cmd") // macOS uses Cmd+V
-	} else {
-		robotgo.KeyTap("v", "ctrl") // Windows/Linux use Ctrl+V
+	if err := t.history.Add(History{
+		Timestamp:    start,
+		Original:     text,
+		Translated:   translated,
+		PromptTitle:  prompt.Title,
+		Model:        cfg.Model,
+		Latency:      latency,
+		SourceLang:   sourceLang,
+		TargetLang:   data.TargetLang,
+		Alternatives: alternatives,
+		Flags:        flags.reason,
+	}); err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to save history: %v", err))
 	}
+	return translated, nil
 }
 
-// restoreClipboard restores the previous clipboard content
-func restoreClipboard(previousContent string) {
-	if previousContent != "" {
-		if err := clipboard.WriteAll(previousContent); err != nil {
-			log.Printf("⚠️  Failed to restore clipboard: %v", err)
+// translate runs the configured translator with the glossary terms and,
+// with Config.PIIMask, personal data masked, logging partial output as it
+// arrives when streaming is enabled and the backend supports it
+func (t *TranslatorApp) translate(ctx context.Context, prompt, text string) (string, error) {
+	cfg := t.currentConfig()
+	var pii []piiMatch
+	if cfg.PIIMask {
+		text, pii = maskPII(text, cfg.PIIPatterns)
+		if len(pii) > 0 {
+			log.Printf("   Masked %d pieces of personal data", len(pii))
+			prompt += piiInstruction
 		}
 	}
+	text, instruction, terms := applyGlossary(text, cfg.Glossary)
+	if len(pii) > 0 || len(terms) > 0 {
+		ctx = withoutChunkSink(ctx)
+	}
+	if req, ok := dryRunFrom(ctx); ok {
+		req.prompt, req.text = prompt+instruction, text
+		return "", errDryRun
+	}
+	translator, limiter := t.backend()
+	if err := limiter.wait(ctx, estimateTokens(prompt+instruction+text)); err != nil {
+		return "", err
+	}
+	if alts, ok := alternativesFrom(ctx); ok {
+		alts.texts = nil
+		if candidates, ok := translator.(CandidatesTranslator); ok {
+			results, err := candidates.TranslateCandidates(ctx, prompt+instruction, text, alternativeCount)
+			if err != nil {
+				return "", err
+			}
+			for i := range results {
+				results[i] = unmaskPII(restoreGlossary(results[i], terms), pii)
+			}
+			alts.texts = results[1:]
+			return results[0], nil
+		}
+	}
+	slog.Debug("sending translation request", "provider", cfg.Provider, "model", cfg.Model, "chars", len(text))
+	result, err := t.runTranslator(ctx, translator, prompt+instruction, text)
+	if err != nil {
+		return "", err
+	}
+	return unmaskPII(restoreGlossary(result, terms), pii), nil
+}
+
+// chunkSinkKey carries the function streamed chunks are passed to
+type chunkSinkKey struct{}
+
+// withChunkSink asks for the translation under ctx to be streamed, with
+// each chunk passed to sink as it arrives
+func withChunkSink(ctx context.Context, sink func(chunk string)) context.Context {
+	return context.WithValue(ctx, chunkSinkKey{}, sink)
+}
+
+// withoutChunkSink stops the chunks under ctx from reaching the sink. It
+// is used when the model's output isn't the result yet, e.g. a chain step
+// before the last or a reply with masked PII or glossary placeholders that
+// are restored afterwards, so the sink only sees text of the result.
+func withoutChunkSink(ctx context.Context) context.Context {
+	return context.WithValue(ctx, chunkSinkKey{}, nil)
+}
+
+func (t *TranslatorApp) runTranslator(ctx context.Context, translator Translator, prompt, text string) (string, error) {
+	sink, _ := ctx.Value(chunkSinkKey{}).(func(string))
+	streamer, ok := translator.(StreamingTranslator)
+	if (!t.currentConfig().Streaming && sink == nil) || !ok {
+		return translator.Translate(ctx, prompt, text)
+	}
+
+	chunks := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		received := 0
+		for chunk := range chunks {
+			received += len(chunk)
+			slog.Debug("stream chunk received", "chars", received)
+			if sink != nil {
+				sink(chunk)
+			}
+		}
+	}()
+
+	result, err := streamer.TranslateStream(ctx, prompt, text, chunks)
+	<-done
+	return result, err
 }
 
 func truncateText(s string, maxLen int) string {
@@ -159,4 +756,4 @@ func truncateText(s string, maxLen int) string {
 		return s
 	}
 	return s[:maxLen] + "..."
-}
\ No newline at end of file
+}
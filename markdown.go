@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// codeSpanPattern matches fenced code blocks and inline code
+var codeSpanPattern = regexp.MustCompile("(?s)```.*?```|`[^`\n]+`")
+
+const preserveMarkdownInstruction = `
+
+Keep the Markdown formatting (headers, bold, italics, lists, links) of the text.
+Placeholders such as __CODE_0__ stand for code and must be copied verbatim.`
+
+// extractCode replaces every code span in text with a numbered placeholder
+// and returns the masked text together with the original spans
+func extractCode(text string) (string, []string) {
+	var spans []string
+	masked := codeSpanPattern.ReplaceAllStringFunc(text, func(span string) string {
+		spans = append(spans, span)
+		return codePlaceholder(len(spans) - 1)
+	})
+	return masked, spans
+}
+
+// restoreCode puts the spans removed by extractCode back in place
+func restoreCode(text string, spans []string) string {
+	for i, span := range spans {
+		text = strings.Replace(text, codePlaceholder(i), span, 1)
+	}
+	return text
+}
+
+func codePlaceholder(i int) string {
+	return fmt.Sprintf("__CODE_%d__", i)
+}
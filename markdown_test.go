@@ -0,0 +1,83 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		wantMasked string
+		wantSpans  []string
+	}{
+		{
+			name:       "no code",
+			text:       "Plain **bold** text",
+			wantMasked: "Plain **bold** text",
+		},
+		{
+			name:       "inline code",
+			text:       "Run `go test` and `go vet` first",
+			wantMasked: "Run __CODE_0__ and __CODE_1__ first",
+			wantSpans:  []string{"`go test`", "`go vet`"},
+		},
+		{
+			name:       "code fence",
+			text:       "Example:\n```go\nfmt.Println(\"hi\")\n```\nDone",
+			wantMasked: "Example:\n__CODE_0__\nDone",
+			wantSpans:  []string{"```go\nfmt.Println(\"hi\")\n```"},
+		},
+		{
+			name:       "inline code inside a fence stays in the fence",
+			text:       "```\nuse `x` here\n```",
+			wantMasked: "__CODE_0__",
+			wantSpans:  []string{"```\nuse `x` here\n```"},
+		},
+		{
+			name:       "links are left to the model",
+			text:       "See [the docs](https://example.com/a_b) for `cfg`",
+			wantMasked: "See [the docs](https://example.com/a_b) for __CODE_0__",
+			wantSpans:  []string{"`cfg`"},
+		},
+		{
+			name:       "code in link text",
+			text:       "[`main.go`](main.go)",
+			wantMasked: "[__CODE_0__](main.go)",
+			wantSpans:  []string{"`main.go`"},
+		},
+		{
+			name:       "backtick across lines is not code",
+			text:       "a ` b\nc ` d",
+			wantMasked: "a ` b\nc ` d",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masked, spans := extractCode(tt.text)
+			if masked != tt.wantMasked {
+				t.Errorf("masked = %q, want %q", masked, tt.wantMasked)
+			}
+			if !slices.Equal(spans, tt.wantSpans) {
+				t.Errorf("spans = %q, want %q", spans, tt.wantSpans)
+			}
+			if got := restoreCode(masked, spans); got != tt.text {
+				t.Errorf("restoreCode() = %q, want the original %q", got, tt.text)
+			}
+		})
+	}
+}
+
+func TestRestoreCodeAfterTranslation(t *testing.T) {
+	masked, spans := extractCode("Call `Open` then `Close`")
+	// The model may reorder the placeholders
+	translated := "Rufe __CODE_1__ nach __CODE_0__ auf"
+	if masked != "Call __CODE_0__ then __CODE_1__" {
+		t.Fatalf("masked = %q", masked)
+	}
+	want := "Rufe `Close` nach `Open` auf"
+	if got := restoreCode(translated, spans); got != want {
+		t.Errorf("restoreCode() = %q, want %q", got, want)
+	}
+}
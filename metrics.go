@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	translationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lingosnap_translations_total",
+		Help: "Translations sent to the model, by outcome.",
+	}, []string{"model", "prompt_title", "status"})
+
+	translationDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "lingosnap_translation_duration_seconds",
+		Help:    "Time taken by translations that reached the model.",
+		Buckets: prometheus.ExponentialBuckets(0.25, 2, 8),
+	})
+
+	cacheHitsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "lingosnap_cache_hits_total",
+		Help: "Translations answered from the cache.",
+	})
+
+	queueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "lingosnap_queue_depth",
+		Help: "Hotkey translations running or waiting in the queue.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(translationsTotal, translationDuration, cacheHitsTotal, queueDepth)
+}
+
+// serveMetrics exposes /metrics in the Prometheus text format on addr
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("📈 Serving metrics on %s/metrics", addr)
+	if err := srv.ListenAndServe(); err != nil {
+		slog.Error(fmt.Sprintf("❌ Metrics server failed: %v", err))
+	}
+}
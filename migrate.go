@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"path/filepath"
+)
+
+// configVersion is the settings.json schema written by this build
+const configVersion = 2
+
+// configMigrations[n] upgrades a settings file from version n to n+1.
+// Append a function here whenever a field changes shape.
+var configMigrations = []func(fields map[string]json.RawMessage) error{
+	migrateV0toV1,
+	migrateV1toV2,
+}
+
+// migrateV0toV1 handles files written before settings.json carried a
+// version. Their fields already have the v1 shape, so only the version
+// is stamped.
+func migrateV0toV1(fields map[string]json.RawMessage) error {
+	return nil
+}
+
+// migrateV1toV2 turns the settings hotkey, new in v2 and on by default,
+// off in files where its default combination is already taken, so they
+// keep loading
+func migrateV1toV2(fields map[string]json.RawMessage) error {
+	if _, ok := fields["settings_hotkey"]; ok {
+		return nil
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	cfg := defaultConfig()
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return err
+	}
+	if checkHotkeyConflicts(cfg) == nil {
+		return nil
+	}
+	cfg.SettingsHotkey = ""
+	if checkHotkeyConflicts(cfg) == nil {
+		fields["settings_hotkey"] = json.RawMessage(`""`)
+		slog.Warn(fmt.Sprintf("⚠️  %s is taken, so the new settings hotkey is off; set settings_hotkey to use it", defaultSettingsHotkey))
+	}
+	return nil
+}
+
+// migrateConfig upgrades raw settings.json data to configVersion and
+// returns the result together with the version it started from
+func migrateConfig(data []byte) ([]byte, int, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, 0, err
+	}
+
+	var version int
+	if raw, ok := fields["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, 0, fmt.Errorf("invalid version: %w", err)
+		}
+	}
+	if version >= configVersion {
+		return data, version, nil
+	}
+
+	for v := version; v < configVersion; v++ {
+		if err := configMigrations[v](fields); err != nil {
+			return nil, version, fmt.Errorf("failed to migrate from version %d: %w", v, err)
+		}
+	}
+	fields["version"] = json.RawMessage(fmt.Sprint(configVersion))
+
+	migrated, err := json.Marshal(fields)
+	if err != nil {
+		return nil, version, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	return migrated, version, nil
+}
+
+// backupConfig keeps a copy of settings.json as it was before migrating
+// away from the given version
+func backupConfig(dir string, data []byte, version int) error {
+	path := filepath.Join(dir, fmt.Sprintf("settings-backup-v%d.json", version))
+	if err := writeConfigFile(path, data); err != nil {
+		return fmt.Errorf("failed to back up config: %w", err)
+	}
+	log.Printf("   Backed up %s to %s", configFileName, path)
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	tests := []struct {
+		name               string
+		data               string
+		wantFrom           int
+		wantVersion        int
+		wantHotkey         string
+		wantSettingsHotkey string
+	}{
+		{
+			name:               "v0 without a version",
+			data:               `{"hotkey": "ctrl+shift+t"}`,
+			wantFrom:           0,
+			wantVersion:        configVersion,
+			wantHotkey:         "ctrl+shift+t",
+			wantSettingsHotkey: defaultSettingsHotkey,
+		},
+		{
+			name:               "v0 taking the settings hotkey",
+			data:               `{"hotkey": "ctrl+alt+s"}`,
+			wantFrom:           0,
+			wantVersion:        configVersion,
+			wantHotkey:         "ctrl+alt+s",
+			wantSettingsHotkey: "",
+		},
+		{
+			name:               "v1 without conflict",
+			data:               `{"version": 1, "hotkey": "ctrl+shift+t"}`,
+			wantFrom:           1,
+			wantVersion:        configVersion,
+			wantHotkey:         "ctrl+shift+t",
+			wantSettingsHotkey: defaultSettingsHotkey,
+		},
+		{
+			name:               "v1 global hotkey takes the settings hotkey",
+			data:               `{"version": 1, "hotkey": "Alt+Ctrl+S"}`,
+			wantFrom:           1,
+			wantVersion:        configVersion,
+			wantHotkey:         "Alt+Ctrl+S",
+			wantSettingsHotkey: "",
+		},
+		{
+			name:               "v1 prompt hotkey takes the settings hotkey",
+			data:               `{"version": 1, "hotkey": "ctrl+shift+t", "prompts": [{"title": "A", "text": "a", "hotkey": "ctrl+alt+s"}]}`,
+			wantFrom:           1,
+			wantVersion:        configVersion,
+			wantHotkey:         "ctrl+shift+t",
+			wantSettingsHotkey: "",
+		},
+		{
+			name:               "v1 with its own settings hotkey",
+			data:               `{"version": 1, "hotkey": "ctrl+alt+s", "settings_hotkey": "ctrl+alt+o"}`,
+			wantFrom:           1,
+			wantVersion:        configVersion,
+			wantHotkey:         "ctrl+alt+s",
+			wantSettingsHotkey: "ctrl+alt+o",
+		},
+		{
+			name:               "current version is left alone",
+			data:               `{"version": 2, "hotkey": "ctrl+alt+s", "settings_hotkey": "ctrl+alt+s"}`,
+			wantFrom:           2,
+			wantVersion:        2,
+			wantHotkey:         "ctrl+alt+s",
+			wantSettingsHotkey: "ctrl+alt+s",
+		},
+		{
+			name:               "newer than configVersion",
+			data:               `{"version": 99, "hotkey": "ctrl+shift+t", "from_the_future": true}`,
+			wantFrom:           99,
+			wantVersion:        99,
+			wantHotkey:         "ctrl+shift+t",
+			wantSettingsHotkey: defaultSettingsHotkey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrated, from, err := migrateConfig([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("migrateConfig() failed: %v", err)
+			}
+			if from != tt.wantFrom {
+				t.Errorf("migrated from %d, want %d", from, tt.wantFrom)
+			}
+			cfg := defaultConfig()
+			if err := json.Unmarshal(migrated, cfg); err != nil {
+				t.Fatalf("migrated config doesn't decode: %v", err)
+			}
+			if cfg.Version != tt.wantVersion {
+				t.Errorf("Version = %d, want %d", cfg.Version, tt.wantVersion)
+			}
+			if cfg.Hotkey != tt.wantHotkey {
+				t.Errorf("Hotkey = %q, want %q", cfg.Hotkey, tt.wantHotkey)
+			}
+			if cfg.SettingsHotkey != tt.wantSettingsHotkey {
+				t.Errorf("SettingsHotkey = %q, want %q", cfg.SettingsHotkey, tt.wantSettingsHotkey)
+			}
+		})
+	}
+}
+
+func TestMigrateConfigInvalid(t *testing.T) {
+	for _, data := range []string{`not json`, `{"version": "two"}`, `[1, 2]`} {
+		if _, _, err := migrateConfig([]byte(data)); err == nil {
+			t.Errorf("migrateConfig(%s) succeeded, want an error", data)
+		}
+	}
+}
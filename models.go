@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// modelListTTL is how long the fetched model list is reused
+const modelListTTL = 24 * time.Hour
+
+// ListModels returns the Gemini models that can generate content
+func (g *GeminiTranslator) ListModels(ctx context.Context) ([]string, error) {
+	_, client, err := g.client(ctx, g.config())
+	if err != nil {
+		return nil, err
+	}
+
+	var models []string
+	for m, err := range client.Models.All(ctx) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list models: %w", err)
+		}
+		name := strings.TrimPrefix(m.Name, "models/")
+		if strings.Contains(name, "gemini") && slices.Contains(m.SupportedActions, "generateContent") {
+			models = append(models, name)
+		}
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("the API returned no Gemini models")
+	}
+	return models, nil
+}
+
+// availableModels returns the models offered by the configured provider.
+// For Gemini the list is fetched from the API at most once per
+// modelListTTL, or earlier with refresh, and cached in the config. The
+// built-in list is used when the provider can't list models or the call
+// fails.
+func (t *TranslatorApp) availableModels(ctx context.Context, refresh bool) []string {
+	cfg := t.currentConfig()
+	fallback := providerModels[cfg.Provider]
+	translator, _ := t.backend()
+	gemini, ok := translator.(*GeminiTranslator)
+	if !ok {
+		return fallback
+	}
+
+	cached, cachedAt := cfg.AvailableModels, cfg.ModelsCachedAt
+	if !refresh && len(cached) > 0 && cachedAt != nil && time.Since(*cachedAt) < modelListTTL {
+		return cached
+	}
+
+	models, err := gemini.ListModels(ctx)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  %v, using the built-in model list", err))
+		if len(cached) > 0 {
+			return cached
+		}
+		return fallback
+	}
+
+	now := time.Now()
+	err = t.updateConfig(func(cfg *Config) error {
+		cfg.AvailableModels, cfg.ModelsCachedAt = models, &now
+		return saveConfig(cfg)
+	})
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to save config: %v", err))
+	}
+	return models
+}
@@ -0,0 +1,91 @@
+//go:build !headless
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"slices"
+
+	"github.com/go-vgo/robotgo"
+)
+
+// cursorMonitor returns the index of the display under the mouse cursor,
+// or -1 when it can't be told
+func cursorMonitor() int {
+	x, y := robotgo.Location()
+	for i := range robotgo.DisplaysNum() {
+		dx, dy, w, h := robotgo.GetDisplayBounds(i)
+		if x >= dx && x < dx+w && y >= dy && y < dy+h {
+			return i
+		}
+	}
+	return -1
+}
+
+// windowOnCursorDisplay returns the position that centers a window of the
+// given size on the display under the mouse cursor, so dialogs open where
+// the user is working rather than on the main display. It's false when the
+// display can't be told.
+func windowOnCursorDisplay(width, height int) (x, y int, ok bool) {
+	i := cursorMonitor()
+	if i < 0 {
+		return 0, 0, false
+	}
+	dx, dy, w, h := robotgo.GetDisplayBounds(i)
+	return dx + max(w-width, 0)/2, dy + max(h-height, 0)/2, true
+}
+
+// onAllowedMonitor reports whether the cursor is on one of
+// Config.AllowedMonitors. An empty list allows every monitor.
+func (t *TranslatorApp) onAllowedMonitor() bool {
+	t.mu.Lock()
+	allowed := t.config.AllowedMonitors
+	t.mu.Unlock()
+	return len(allowed) == 0 || slices.Contains(allowed, cursorMonitor())
+}
+
+// fitToScreen shrinks a width and height to the main screen, which may be
+// smaller than when they were set after monitors changed. 0 stays 0.
+func fitToScreen(width, height int) (int, int) {
+	screenW, screenH := robotgo.GetScreenSize()
+	if screenW > 0 && width > screenW {
+		width = screenW
+	}
+	if screenH > 0 && height > screenH {
+		height = screenH
+	}
+	return max(width, 0), max(height, 0)
+}
+
+// fitScaleToScreen lowers scale so a dialog of the default editor size
+// still fits the screen, e.g. when settings from a high-DPI machine are
+// used on a small display
+func fitScaleToScreen(scale float32) float32 {
+	screenW, screenH := robotgo.GetScreenSize()
+	if screenW <= 0 || screenH <= 0 {
+		return scale
+	}
+	fits := min(float32(screenW)/editorWidth, float32(screenH)/editorHeight)
+	if scale > fits {
+		fitted := max(fits, minUIScale)
+		slog.Warn(fmt.Sprintf("⚠️  ui_scale %g doesn't fit the %dx%d screen, using %.2g", scale, screenW, screenH, fitted))
+		return fitted
+	}
+	return scale
+}
+
+// logMonitors prints the detected displays so their indexes can be used in
+// allowed_monitors
+func logMonitors() {
+	n := robotgo.DisplaysNum()
+	if n < 2 {
+		return
+	}
+	log.Printf("   %d monitors found:", n)
+	for i := range n {
+		x, y, w, h := robotgo.GetDisplayBounds(i)
+		log.Printf("     %d: %dx%d at %d,%d", i, w, h, x, y)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !headless
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// notifySuccess tells the user a hotkey translation was pasted, unless
+// Config.Notifications is off
+func (t *TranslatorApp) notifySuccess(latency time.Duration) {
+	if !t.currentConfig().Notifications {
+		return
+	}
+	go sendNotification("LingoSnap", fmt.Sprintf("Translation complete (%dms)", latency.Milliseconds()))
+}
+
+// notifyCopied tells the user a copy hotkey translation is on the
+// clipboard. It is shown even with Config.Notifications off, as nothing
+// else shows the translation is ready.
+func (t *TranslatorApp) notifyCopied() {
+	go sendNotification("LingoSnap", "Translation copied")
+}
+
+// notifySameLanguage warns that the text seems to be in the target
+// language already. Like notifyCopied it is always shown, as the request
+// still costs a call.
+func (t *TranslatorApp) notifySameLanguage(lang string) {
+	go sendNotification("LingoSnap", fmt.Sprintf("Text appears to already be in %s — translating anyway", lang))
+}
+
+// notifyError tells the user a hotkey translation failed, titled with the
+// kind of error
+func (t *TranslatorApp) notifyError(err error) {
+	if !t.currentConfig().Notifications {
+		return
+	}
+	go sendNotification(errorCategory(err), truncateText(err.Error(), 80))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+)
+
+const (
+	ocrTesseract    = "tesseract"
+	ocrGoogleVision = "google_vision"
+
+	visionEndpoint = "https://vision.googleapis.com/v1/images:annotate"
+)
+
+// extractText runs OCR on a PNG image with the configured provider
+func extractText(ctx context.Context, cfg *Config, png []byte) (string, error) {
+	switch cfg.OCRProvider {
+	case ocrTesseract, "":
+		return tesseractOCR(ctx, cfg, png)
+	case ocrGoogleVision:
+		client, err := newHTTPClient(cfg)
+		if err != nil {
+			return "", err
+		}
+		key, err := resolveSecret(cfg.VisionAPIKey, accountVisionAPIKey)
+		if err != nil {
+			return "", err
+		}
+		return visionOCR(ctx, client, key, png)
+	default:
+		return "", fmt.Errorf("unknown OCR provider %q", cfg.OCRProvider)
+	}
+}
+
+// tesseractOCR pipes the image through the tesseract command line tool
+func tesseractOCR(ctx context.Context, cfg *Config, png []byte) (string, error) {
+	path := cfg.TesseractPath
+	if path == "" {
+		path = "tesseract"
+	}
+	args := []string{"stdin", "stdout"}
+	if cfg.TesseractLang != "" {
+		args = append(args, "-l", cfg.TesseractLang)
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = bytes.NewReader(png)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("tesseract failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+type visionRequest struct {
+	Requests []visionAnnotateRequest `json:"requests"`
+}
+
+type visionAnnotateRequest struct {
+	Image struct {
+		Content string `json:"content"`
+	} `json:"image"`
+	Features []visionFeature `json:"features"`
+}
+
+type visionFeature struct {
+	Type string `json:"type"`
+}
+
+type visionResponse struct {
+	Responses []struct {
+		FullTextAnnotation *struct {
+			Text string `json:"text"`
+		} `json:"fullTextAnnotation"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"responses"`
+}
+
+// visionOCR sends the image to the Google Cloud Vision text detection API
+func visionOCR(ctx context.Context, client *http.Client, apiKey string, png []byte) (string, error) {
+	if apiKey == "" {
+		return "", fmt.Errorf("vision_api_key must be set to use the %s OCR provider", ocrGoogleVision)
+	}
+
+	var annotate visionAnnotateRequest
+	annotate.Image.Content = base64.StdEncoding.EncodeToString(png)
+	annotate.Features = []visionFeature{{Type: "TEXT_DETECTION"}}
+
+	body, err := json.Marshal(visionRequest{Requests: []visionAnnotateRequest{annotate}})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, visionEndpoint+"?key="+apiKey, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vision request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result visionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode vision response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if len(result.Responses) == 0 {
+		return "", fmt.Errorf("vision request failed: HTTP %d", resp.StatusCode)
+	}
+	r := result.Responses[0]
+	if r.Error != nil {
+		return "", fmt.Errorf("vision request failed: %s", r.Error.Message)
+	}
+	if r.FullTextAnnotation == nil {
+		return "", nil
+	}
+	return strings.TrimSpace(r.FullTextAnnotation.Text), nil
+}
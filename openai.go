@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAITranslator translates through any OpenAI-compatible chat
+// completions endpoint (OpenAI, Together, Groq, Ollama, ...)
+type OpenAITranslator struct {
+	baseURL string
+	model   string
+	apiKey  string
+	client  *http.Client
+	usage   *usageTracker
+}
+
+func newOpenAITranslator(baseURL, model, apiKey string, client *http.Client, usage *usageTracker) *OpenAITranslator {
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	return &OpenAITranslator{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		apiKey:  apiKey,
+		client:  client,
+		usage:   usage,
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	N        int           `json:"n,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int64 `json:"prompt_tokens"`
+		CompletionTokens int64 `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (o *OpenAITranslator) Translate(ctx context.Context, prompt, text string) (string, error) {
+	choices, err := o.complete(ctx, prompt, text, 0)
+	if err != nil {
+		return "", err
+	}
+	return choices[0], nil
+}
+
+// TranslateCandidates asks for n choices in one request. Servers that
+// ignore n return fewer.
+func (o *OpenAITranslator) TranslateCandidates(ctx context.Context, prompt, text string, n int) ([]string, error) {
+	return o.complete(ctx, prompt, text, n)
+}
+
+func (o *OpenAITranslator) complete(ctx context.Context, prompt, text string, n int) ([]string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model: o.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: text},
+		},
+		N: n,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// Local servers such as Ollama don't need a key
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != nil {
+			return nil, fmt.Errorf("generation failed (HTTP %d): %s", resp.StatusCode, result.Error.Message)
+		}
+		return nil, fmt.Errorf("generation failed: HTTP %d", resp.StatusCode)
+	}
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("generation failed: empty response")
+	}
+	if result.Usage != nil {
+		o.usage.add(o.model, result.Usage.PromptTokens, result.Usage.CompletionTokens)
+	}
+
+	choices := make([]string, len(result.Choices))
+	for i, c := range result.Choices {
+		choices[i] = strings.TrimSpace(c.Message.Content)
+	}
+	return choices, nil
+}
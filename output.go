@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const (
+	outputPlain = "plain"
+	outputJSON  = "json"
+)
+
+const jsonOutputInstruction = `
+
+Respond with only a JSON object of this shape, without code fences:
+{"translated": "<the result>", "source_lang": "<ISO 639-1 code of the input>", "confidence": <0 to 1>, "alternatives": ["<other possible results>"]}`
+
+// TranslationResult is the response requested in the json output format
+type TranslationResult struct {
+	Translated   string   `json:"translated"`
+	SourceLang   string   `json:"source_lang"`
+	Confidence   float64  `json:"confidence"`
+	Alternatives []string `json:"alternatives"`
+}
+
+// parseTranslationResult decodes a json output format response, tolerating
+// the code fences some models add anyway
+func parseTranslationResult(response string) (TranslationResult, error) {
+	response = strings.TrimSpace(response)
+	response = strings.TrimPrefix(response, "```json")
+	response = strings.TrimPrefix(response, "```")
+	response = strings.TrimSuffix(response, "```")
+
+	var result TranslationResult
+	if err := json.Unmarshal([]byte(response), &result); err != nil {
+		return TranslationResult{}, err
+	}
+	if strings.TrimSpace(result.Translated) == "" {
+		return TranslationResult{}, fmt.Errorf("response has no translated text")
+	}
+	return result, nil
+}
+
+// unwrapJSONResult returns the translated text of a json output format
+// response, saving the full result to Config.OutputJSONPath if set. A
+// response that isn't valid JSON is used as plain text.
+func (t *TranslatorApp) unwrapJSONResult(response string, codeSpans []string) (translated, sourceLang string) {
+	result, err := parseTranslationResult(response)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Model didn't return valid JSON, using the plain response: %v", err))
+		return response, ""
+	}
+	if path := t.currentConfig().OutputJSONPath; path != "" {
+		saved := result
+		saved.Translated = restoreCode(result.Translated, codeSpans)
+		saved.Alternatives = make([]string, len(result.Alternatives))
+		for i, alt := range result.Alternatives {
+			saved.Alternatives[i] = restoreCode(alt, codeSpans)
+		}
+		writeTranslationResult(path, saved)
+	}
+	return result.Translated, strings.ToLower(result.SourceLang)
+}
+
+// writeTranslationResult saves the full result to path for other tools
+func writeTranslationResult(path string, result TranslationResult) {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to encode translation result: %v", err))
+		return
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to write %s: %v", path, err))
+	}
+}
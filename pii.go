@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// piiPattern finds one kind of personal data
+type piiPattern struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// builtinPIIPatterns are checked in order, so the more specific formats
+// come before the phone number pattern that would also match them. After
+// a country code, phone numbers may have an area code without brackets,
+// as in +374 91 234567.
+var builtinPIIPatterns = []piiPattern{
+	{"EMAIL", regexp.MustCompile(`[\w.+-]+@[\w-]+(?:\.[\w-]+)+`)},
+	{"CARD", regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)},
+	{"SSN", regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	{"PHONE", regexp.MustCompile(`(?:\+\d{1,3}[ .-]?(?:\d{1,4}[ .-])?)?(?:\(\d{2,4}\)[ .-]?)?\b\d{3}[ .-]?\d{3,4}(?:[ .-]?\d{2,4})?\b`)},
+	{"NAME", regexp.MustCompile(`\b(?:Mr|Mrs|Ms|Miss|Dr|Prof)\.? [A-Z][a-z]+(?: [A-Z][a-z]+)?`)},
+}
+
+// piiMatch is a piece of personal data replaced by a placeholder
+type piiMatch struct {
+	placeholder string
+	value       string
+}
+
+const piiInstruction = "\n\nCopy placeholders such as <EMAIL_1> verbatim; they stand for personal data."
+
+// maskPII replaces the personal data in text with typed placeholders such
+// as <EMAIL_1>, using the built-in patterns and then the custom ones. The
+// same value always gets the same placeholder. It returns the masked text
+// and the matches unmaskPII needs.
+func maskPII(text string, custom []string) (string, []piiMatch) {
+	patterns := builtinPIIPatterns
+	for _, p := range custom {
+		// checkPIIPatterns has already rejected invalid patterns
+		if re, err := regexp.Compile(p); err == nil {
+			patterns = append(patterns, piiPattern{"CUSTOM", re})
+		}
+	}
+
+	var matches []piiMatch
+	placeholders := make(map[string]string)
+	counts := make(map[string]int)
+	for _, p := range patterns {
+		text = p.pattern.ReplaceAllStringFunc(text, func(value string) string {
+			if placeholder, ok := placeholders[value]; ok {
+				return placeholder
+			}
+			counts[p.kind]++
+			placeholder := fmt.Sprintf("<%s_%d>", p.kind, counts[p.kind])
+			placeholders[value] = placeholder
+			matches = append(matches, piiMatch{placeholder: placeholder, value: value})
+			return placeholder
+		})
+	}
+	return text, matches
+}
+
+// unmaskPII puts the original values back in place of the placeholders
+func unmaskPII(text string, matches []piiMatch) string {
+	for _, m := range matches {
+		text = strings.ReplaceAll(text, m.placeholder, m.value)
+	}
+	return text
+}
+
+// checkPIIPatterns reports the first custom PII pattern that doesn't
+// compile
+func checkPIIPatterns(cfg *Config) error {
+	for _, p := range cfg.PIIPatterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("invalid pii_patterns entry %q: %w", p, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import "testing"
+
+func TestMaskPII(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		custom     []string
+		wantMasked string
+	}{
+		{
+			name:       "nothing to mask",
+			text:       "The meeting is on Friday.",
+			wantMasked: "The meeting is on Friday.",
+		},
+		{
+			name:       "emails",
+			text:       "Write to anna.k+work@mail.example.am or bob@example.com",
+			wantMasked: "Write to <EMAIL_1> or <EMAIL_2>",
+		},
+		{
+			name:       "repeated value keeps its placeholder",
+			text:       "bob@example.com, again bob@example.com",
+			wantMasked: "<EMAIL_1>, again <EMAIL_1>",
+		},
+		{
+			name:       "phones",
+			text:       "Call +374 91 234567 or (212) 555-1234",
+			wantMasked: "Call <PHONE_1> or <PHONE_2>",
+		},
+		{
+			name:       "cards",
+			text:       "Card 4111 1111 1111 1111 and 5500-0000-0000-0004",
+			wantMasked: "Card <CARD_1> and <CARD_2>",
+		},
+		{
+			name:       "ssn before phone",
+			text:       "SSN 123-45-6789",
+			wantMasked: "SSN <SSN_1>",
+		},
+		{
+			name:       "names with titles",
+			text:       "Dr. Anna Petrosyan will see Mr Smith",
+			wantMasked: "<NAME_1> will see <NAME_2>",
+		},
+		{
+			name:       "custom pattern",
+			text:       "Order ORD-00042 for anna@example.com",
+			custom:     []string{`ORD-\d+`},
+			wantMasked: "Order <CUSTOM_1> for <EMAIL_1>",
+		},
+		{
+			name:       "mixed",
+			text:       "Reach Ms Grigoryan at anna@example.com or 091 234 567, card 4111111111111111.",
+			wantMasked: "Reach <NAME_1> at <EMAIL_1> or <PHONE_1>, card <CARD_1>.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masked, matches := maskPII(tt.text, tt.custom)
+			if masked != tt.wantMasked {
+				t.Errorf("maskPII() = %q, want %q", masked, tt.wantMasked)
+			}
+			if got := unmaskPII(masked, matches); got != tt.text {
+				t.Errorf("unmaskPII(maskPII()) = %q, want the original %q", got, tt.text)
+			}
+		})
+	}
+}
+
+func TestUnmaskPIIAfterTranslation(t *testing.T) {
+	text := "x@example.com y@example.com 4111 1111 1111 1111"
+	masked, matches := maskPII(text, nil)
+	if masked != "<EMAIL_1> <EMAIL_2> <CARD_1>" {
+		t.Fatalf("maskPII() = %q", masked)
+	}
+	// The model may reorder the placeholders and repeat them
+	translated := "<CARD_1>: <EMAIL_2>, <EMAIL_1>, <EMAIL_2>"
+	want := "4111 1111 1111 1111: y@example.com, x@example.com, y@example.com"
+	if got := unmaskPII(translated, matches); got != want {
+		t.Errorf("unmaskPII() = %q, want %q", got, want)
+	}
+}
+
+func TestCheckPIIPatterns(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.PIIPatterns = []string{`ORD-\d+`}
+	if err := checkPIIPatterns(cfg); err != nil {
+		t.Errorf("checkPIIPatterns() rejected a valid pattern: %v", err)
+	}
+	cfg.PIIPatterns = append(cfg.PIIPatterns, `(unclosed`)
+	if err := checkPIIPatterns(cfg); err == nil {
+		t.Error("checkPIIPatterns() accepted an invalid pattern")
+	}
+}
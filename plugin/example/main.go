@@ -0,0 +1,29 @@
+// Command example is a no-op LingoSnap translator plugin that returns the
+// text unchanged. Build it with:
+//
+//	go build -buildmode=plugin -o noop.so ./plugin/example
+//
+// then copy noop.so to plugin_dir and set provider to "noop".
+package main
+
+import (
+	"context"
+
+	"github.com/Vardan1995/lingosnap/plugin"
+)
+
+type noop struct{}
+
+func (noop) Name() string {
+	return "noop"
+}
+
+func (noop) Translate(ctx context.Context, prompt, text string) (string, error) {
+	return text, nil
+}
+
+// Translator is the symbol LingoSnap looks up
+var Translator plugin.TranslatorPlugin = noop{}
+
+// main is never run; it lets the package build without -buildmode=plugin
+func main() {}
@@ -0,0 +1,26 @@
+// Package plugin defines the interface LingoSnap translation backends
+// loaded from Go plugins implement.
+//
+// A plugin is a package main built with -buildmode=plugin that exports a
+// variable named Translator:
+//
+//	var Translator plugin.TranslatorPlugin = myTranslator{}
+//
+// Put the .so file in plugin_dir and set provider to the plugin's Name to
+// use it. See the example directory for a complete plugin.
+package plugin
+
+import "context"
+
+// TranslatorPlugin is a translation backend loaded at startup
+type TranslatorPlugin interface {
+	// Name identifies the backend; provider in settings.json selects it
+	Name() string
+
+	// Translate sends the rendered prompt and the text to translate and
+	// returns the translation. ctx expires after the request timeout.
+	Translate(ctx context.Context, prompt, text string) (string, error)
+}
+
+// SymbolName is the exported variable LingoSnap looks up in each plugin
+const SymbolName = "Translator"
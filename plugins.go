@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	goplugin "plugin"
+	"strings"
+	"time"
+
+	"github.com/Vardan1995/lingosnap/plugin"
+)
+
+// loadPlugins opens every .so file in dir and returns the translators they
+// export, by name. A plugin that fails to load is logged and skipped.
+func loadPlugins(dir string) map[string]plugin.TranslatorPlugin {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to read plugin_dir: %v", err))
+		return nil
+	}
+
+	plugins := make(map[string]plugin.TranslatorPlugin)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".so") {
+			continue
+		}
+		p, err := openPlugin(filepath.Join(dir, e.Name()))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("⚠️  Skipping plugin %s: %v", e.Name(), err))
+			continue
+		}
+		plugins[p.Name()] = p
+		log.Printf("   Loaded translator plugin %q from %s", p.Name(), e.Name())
+	}
+	return plugins
+}
+
+// openPlugin loads the Translator symbol of the plugin at path, declared
+// either as a variable or as a value
+func openPlugin(path string) (plugin.TranslatorPlugin, error) {
+	p, err := goplugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup(plugin.SymbolName)
+	if err != nil {
+		return nil, err
+	}
+	switch v := sym.(type) {
+	case *plugin.TranslatorPlugin:
+		if *v == nil {
+			return nil, fmt.Errorf("%s is nil", plugin.SymbolName)
+		}
+		return *v, nil
+	case plugin.TranslatorPlugin:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%s is a %T, not a plugin.TranslatorPlugin", plugin.SymbolName, sym)
+	}
+}
+
+// pluginTranslator runs a plugin's translations on their own goroutine so
+// a plugin that ignores its context can't hold up the caller past the
+// timeout
+type pluginTranslator struct {
+	plugin  plugin.TranslatorPlugin
+	timeout time.Duration
+}
+
+func (p *pluginTranslator) Translate(ctx context.Context, prompt, text string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	type result struct {
+		text string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		text, err := p.plugin.Translate(ctx, prompt, text)
+		done <- result{text, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return "", fmt.Errorf("plugin %s failed: %w", p.plugin.Name(), r.err)
+		}
+		return r.text, nil
+	case <-ctx.Done():
+		return "", fmt.Errorf("plugin %s failed: %w", p.plugin.Name(), ctx.Err())
+	}
+}
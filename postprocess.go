@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// PostProcessRule rewrites every match of Pattern in a translation with
+// Replacement, which may refer to groups as $1 or ${name}
+type PostProcessRule struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// applyPostProcess applies Config.PostProcessRules to a translation in
+// order
+func (t *TranslatorApp) applyPostProcess(text string) string {
+	for _, r := range t.currentConfig().PostProcessRules {
+		// checkPostProcessRules has already rejected invalid patterns
+		text = regexp.MustCompile(r.Pattern).ReplaceAllString(text, r.Replacement)
+	}
+	return text
+}
+
+// checkPostProcessRules rejects rules whose pattern doesn't compile
+func checkPostProcessRules(cfg *Config) error {
+	for i, r := range cfg.PostProcessRules {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern in post_process_rules entry %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
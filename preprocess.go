@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// PreProcessRule rewrites every match of Pattern in the text to translate
+// with Replacement, like PostProcessRule does in translations
+type PreProcessRule PostProcessRule
+
+// builtinPreProcessRule is an optional rule turned on by its name in
+// Config.PreProcessDefaults
+type builtinPreProcessRule struct {
+	name, label string
+	rule        PreProcessRule
+}
+
+// builtinPreProcessRules run before Config.PreProcessRules, in this order
+var builtinPreProcessRules = []builtinPreProcessRule{
+	{"newlines", "Normalize Line Breaks", PreProcessRule{Pattern: `\r\n?`, Replacement: "\n"}},
+	{"tabs", "Replace Tabs with Spaces", PreProcessRule{Pattern: `\t`, Replacement: " "}},
+	{"spaces", "Collapse Repeated Spaces", PreProcessRule{Pattern: ` {2,}`, Replacement: " "}},
+}
+
+// applyPreProcess applies the enabled built-in rules, then
+// Config.PreProcessRules, to the text to translate in order
+func (t *TranslatorApp) applyPreProcess(text string) string {
+	cfg := t.currentConfig()
+	for _, b := range builtinPreProcessRules {
+		if slices.Contains(cfg.PreProcessDefaults, b.name) {
+			text = regexp.MustCompile(b.rule.Pattern).ReplaceAllString(text, b.rule.Replacement)
+		}
+	}
+	for _, r := range cfg.PreProcessRules {
+		// checkPreProcessRules has already rejected invalid patterns
+		text = regexp.MustCompile(r.Pattern).ReplaceAllString(text, r.Replacement)
+	}
+	return text
+}
+
+// checkPreProcessRules rejects rules whose pattern doesn't compile and
+// unknown built-in rules
+func checkPreProcessRules(cfg *Config) error {
+	for i, r := range cfg.PreProcessRules {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern in pre_process_rules entry %d: %w", i+1, err)
+		}
+	}
+	for _, name := range cfg.PreProcessDefaults {
+		if !slices.ContainsFunc(builtinPreProcessRules, func(b builtinPreProcessRule) bool { return b.name == name }) {
+			return fmt.Errorf(`unknown pre_process_defaults rule %q, use "newlines", "tabs" or "spaces"`, name)
+		}
+	}
+	return nil
+}
+
+// setPreProcessDefault turns the named built-in rule on or off and saves
+// the config
+func (t *TranslatorApp) setPreProcessDefault(name string, on bool) error {
+	return t.updateConfig(func(cfg *Config) error {
+		cfg.PreProcessDefaults = slices.DeleteFunc(cfg.PreProcessDefaults, func(n string) bool { return n == name })
+		if on {
+			cfg.PreProcessDefaults = append(cfg.PreProcessDefaults, name)
+		}
+		return saveConfig(cfg)
+	})
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// AppProfile runs a prompt when the global hotkey is pressed in a matching
+// application. ProcessName is compared with both the process name and the
+// window title, as a substring or as a glob such as "*code*".
+type AppProfile struct {
+	ProcessName string `json:"process_name"`
+	PromptTitle string `json:"prompt_title"`
+}
+
+// matches reports whether the profile applies to the given window
+func (p AppProfile) matches(process, title string) bool {
+	pattern := strings.ToLower(strings.TrimSpace(p.ProcessName))
+	if pattern == "" {
+		return false
+	}
+	for _, s := range []string{strings.ToLower(process), strings.ToLower(title)} {
+		if s == "" {
+			continue
+		}
+		if strings.ContainsAny(pattern, "*?[") {
+			if ok, _ := path.Match(pattern, s); ok {
+				return true
+			}
+		} else if strings.Contains(s, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// appProfilePrompt returns the prompt title of the first profile matching
+// the window, or false when none does
+func appProfilePrompt(profiles []AppProfile, process, title string) (string, bool) {
+	for _, profile := range profiles {
+		if profile.matches(process, title) {
+			return profile.PromptTitle, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,188 @@
+//go:build !headless
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// editSelectedPrompt opens the text of the selected prompt in an editable
+// window and saves it. The text before the edit can be restored with
+// undoPromptEdit.
+func (t *TranslatorApp) editSelectedPrompt() {
+	i := t.selectedIndex()
+	if i == 0 {
+		showWarning("The default prompt can't be edited; select one of your prompts")
+		return
+	}
+	prompt := t.prompts()[i]
+	text, ok := editText("Edit Prompt — "+prompt.Title, prompt.Text)
+	if !ok || text == prompt.Text {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.setPromptText(i-1, text); err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to save prompt: %v", err))
+		return
+	}
+	t.promptUndoStack(prompt.Title).record(prompt.Text)
+	log.Printf("✅ Saved %q", prompt.Title)
+}
+
+// quickEditPrompt edits the title and the first line of the selected
+// prompt in two single-line dialogs, for small corrections. An empty
+// answer keeps that part.
+func (t *TranslatorApp) quickEditPrompt() {
+	i := t.selectedIndex()
+	if i == 0 {
+		showWarning("The default prompt can't be edited; select one of your prompts")
+		return
+	}
+	prompt := t.prompts()[i]
+	dialogTitle := "Quick Edit — " + prompt.Title
+	title, ok := askTextDefault(dialogTitle, "Title:", prompt.Title)
+	if !ok {
+		return
+	}
+	first, rest, multiline := strings.Cut(prompt.Text, "\n")
+	line, ok := askTextDefault(dialogTitle, "First line of the prompt:", first)
+	if !ok {
+		return
+	}
+	title = cmp.Or(strings.TrimSpace(title), prompt.Title)
+	text := cmp.Or(line, first)
+	if multiline {
+		text += "\n" + rest
+	}
+
+	t.mu.Lock()
+	if text != prompt.Text {
+		if err := t.setPromptText(i-1, text); err != nil {
+			t.mu.Unlock()
+			slog.Warn(fmt.Sprintf("⚠️  Failed to save prompt: %v", err))
+			return
+		}
+		t.promptUndoStack(prompt.Title).record(prompt.Text)
+		log.Printf("✅ Saved %q", prompt.Title)
+	}
+	if title == prompt.Title {
+		t.mu.Unlock()
+		return
+	}
+	if err := t.swapConfig(func(cfg *Config) error { return renamePrompt(cfg, prompt.Title, title) }); err != nil {
+		t.mu.Unlock()
+		showWarning("Failed to rename the prompt: " + err.Error())
+		return
+	}
+	if stack, ok := t.promptUndo[prompt.Title]; ok {
+		t.promptUndo[title] = stack
+		delete(t.promptUndo, prompt.Title)
+	}
+	onRename := t.onPromptRename
+	t.mu.Unlock()
+
+	if onRename != nil {
+		onRename(i, title)
+	}
+	log.Printf("✅ Renamed %q to %q", prompt.Title, title)
+}
+
+// undoPromptEdit restores the selected prompt's text from before its last
+// edit, or with redo set, reapplies the last undone edit
+func (t *TranslatorApp) undoPromptEdit(redo bool) {
+	i := t.selectedIndex()
+	if i == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prompt := t.config.Prompts[i-1]
+	stack := t.promptUndoStack(prompt.Title)
+	step, action := stack.undoEdit, "Undid"
+	if redo {
+		step, action = stack.redoEdit, "Redid"
+	}
+	text, ok := step(prompt.Text)
+	if !ok {
+		log.Printf("   Nothing to undo or redo in %q", prompt.Title)
+		return
+	}
+	if err := t.setPromptText(i-1, text); err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to save prompt: %v", err))
+		return
+	}
+	log.Printf("🔄 %s the last edit of %q", action, prompt.Title)
+}
+
+// showPromptHistory lists the earlier versions of the selected prompt and
+// shows how the chosen one differs from the current text, offering to
+// restore it
+func (t *TranslatorApp) showPromptHistory() {
+	i := t.selectedIndex()
+	if i == 0 {
+		showWarning("The default prompt has no history; select one of your prompts")
+		return
+	}
+	t.mu.Lock()
+	prompt := t.config.Prompts[i-1]
+	versions := promptVersions(t.config, prompt.Title)
+	t.mu.Unlock()
+	if len(versions) == 0 {
+		showWarning("No earlier versions of " + prompt.Title)
+		return
+	}
+
+	labels := make([]string, len(versions))
+	for j, v := range versions {
+		labels[j] = v.SavedAt.Local().Format("2006-01-02 15:04:05") + "  " + truncateText(v.Text, 60)
+	}
+	title := "Prompt History — " + prompt.Title
+	j, ok := chooseText(title, "Choose a version to compare with the current text:", labels)
+	if !ok {
+		return
+	}
+	diff := formatDiff(wordDiff(versions[j].Text, prompt.Text))
+	if !showDialog(title, "Changes since this version ([-removed-] {+added+}):\n\n"+diff, "Restore", "Close", 0) {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current := t.config.Prompts[i-1].Text
+	if err := t.setPromptText(i-1, versions[j].Text); err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to save prompt: %v", err))
+		return
+	}
+	t.promptUndoStack(prompt.Title).record(current)
+	log.Printf("✅ Restored %q from %s", prompt.Title, versions[j].SavedAt.Local().Format("2006-01-02 15:04:05"))
+}
+
+// setPromptText saves text as the text of the user's prompt at index i.
+// t.mu must be held.
+func (t *TranslatorApp) setPromptText(i int, text string) error {
+	return t.swapConfig(func(cfg *Config) error {
+		cfg.Prompts[i].Text = text
+		return saveConfig(cfg)
+	})
+}
+
+// promptUndoStack returns the undo stack of the prompt with the given
+// title, creating it on first use. t.mu must be held.
+func (t *TranslatorApp) promptUndoStack(title string) *undoStack {
+	if t.promptUndo == nil {
+		t.promptUndo = make(map[string]*undoStack)
+	}
+	stack, ok := t.promptUndo[title]
+	if !ok {
+		stack = &undoStack{}
+		t.promptUndo[title] = stack
+	}
+	return stack
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// promptLibraryVersion is the schema version written to exported files
+const promptLibraryVersion = 1
+
+// promptLibrary is the file format used to share prompts
+type promptLibrary struct {
+	Version int      `json:"version"`
+	Prompts []Prompt `json:"prompts"`
+}
+
+// exportPrompts writes the user's prompts to path
+func exportPrompts(path string, prompts []Prompt) error {
+	data, err := json.MarshalIndent(promptLibrary{Version: promptLibraryVersion, Prompts: prompts}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode prompts: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// readPromptLibrary loads and validates an exported prompt file. Prompts
+// repeating an earlier title in the same file are dropped.
+func readPromptLibrary(path string) ([]Prompt, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var lib promptLibrary
+	if err := json.Unmarshal(data, &lib); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if lib.Version > promptLibraryVersion {
+		return nil, fmt.Errorf("%s has schema version %d, this version of LingoSnap supports up to %d",
+			path, lib.Version, promptLibraryVersion)
+	}
+
+	var prompts []Prompt
+	seen := make(map[string]bool)
+	for i, p := range lib.Prompts {
+		if strings.TrimSpace(p.Title) == "" || strings.TrimSpace(p.Text) == "" {
+			return nil, fmt.Errorf("prompt %d in %s is missing a title or text", i+1, path)
+		}
+		if seen[p.Title] {
+			continue
+		}
+		seen[p.Title] = true
+		prompts = append(prompts, p)
+	}
+	return prompts, nil
+}
+
+// mergePrompts combines existing and imported prompts. With replace the
+// imported prompts are returned as is; otherwise an imported prompt
+// overwrites the existing prompt with the same title and new titles are
+// appended.
+func mergePrompts(existing, imported []Prompt, replace bool) []Prompt {
+	if replace {
+		return append([]Prompt(nil), imported...)
+	}
+
+	merged := append([]Prompt(nil), existing...)
+	for _, p := range imported {
+		found := false
+		for i := range merged {
+			if merged[i].Title == p.Title {
+				merged[i] = p
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+// importPrompts merges the prompts in path into cfg and saves it
+func importPrompts(cfg *Config, path string, replace bool) error {
+	imported, err := readPromptLibrary(path)
+	if err != nil {
+		return err
+	}
+
+	previous := cfg.Prompts
+	cfg.Prompts = mergePrompts(cfg.Prompts, imported, replace)
+	if err := saveConfig(cfg); err != nil {
+		cfg.Prompts = previous
+		return err
+	}
+	return nil
+}
+
+// movePrompt moves the user prompt with the given title to position
+// (1-based) and saves cfg. The default prompt always stays first, and the
+// selection follows the prompt it pointed to.
+func movePrompt(cfg *Config, title string, position int) error {
+	from := slices.IndexFunc(cfg.Prompts, func(p Prompt) bool { return p.Title == title })
+	if from < 0 {
+		return fmt.Errorf("no prompt titled %q", title)
+	}
+	if position < 1 || position > len(cfg.Prompts) {
+		return fmt.Errorf("position must be between 1 and %d, got %d", len(cfg.Prompts), position)
+	}
+	to := position - 1
+
+	previous, previousIndex := cfg.Prompts, cfg.SelectedIndex
+	prompts := slices.Delete(slices.Clone(cfg.Prompts), from, from+1)
+	cfg.Prompts = slices.Insert(prompts, to, previous[from])
+
+	// SelectedIndex counts the default prompt, so user prompt i is i+1
+	switch selected := previousIndex - 1; {
+	case selected == from:
+		cfg.SelectedIndex = to + 1
+	case from < selected && selected <= to:
+		cfg.SelectedIndex--
+	case to <= selected && selected < from:
+		cfg.SelectedIndex++
+	}
+
+	if err := saveConfig(cfg); err != nil {
+		cfg.Prompts, cfg.SelectedIndex = previous, previousIndex
+		return err
+	}
+	return nil
+}
+
+// duplicatePrompt inserts a copy of the user prompt with the given title
+// right after it, titled "<title> (copy)", and saves cfg. It returns the
+// title of the copy.
+func duplicatePrompt(cfg *Config, title string) (string, error) {
+	from := slices.IndexFunc(cfg.Prompts, func(p Prompt) bool { return p.Title == title })
+	if from < 0 {
+		return "", fmt.Errorf("no prompt titled %q", title)
+	}
+
+	taken := func(t string) bool {
+		return slices.ContainsFunc(cfg.Prompts, func(p Prompt) bool { return p.Title == t })
+	}
+	dup := cfg.Prompts[from]
+	dup.Title = title + " (copy)"
+	for n := 2; taken(dup.Title); n++ {
+		dup.Title = fmt.Sprintf("%s (copy %d)", title, n)
+	}
+
+	previous, previousIndex := cfg.Prompts, cfg.SelectedIndex
+	cfg.Prompts = slices.Insert(slices.Clone(cfg.Prompts), from+1, dup)
+	// SelectedIndex counts the default prompt, so user prompt i is i+1
+	if previousIndex-1 > from {
+		cfg.SelectedIndex++
+	}
+
+	if err := saveConfig(cfg); err != nil {
+		cfg.Prompts, cfg.SelectedIndex = previous, previousIndex
+		return "", err
+	}
+	return dup.Title, nil
+}
+
+// setPromptPinned pins or unpins the user prompt with the given title and
+// saves cfg
+func setPromptPinned(cfg *Config, title string, pinned bool) error {
+	i := slices.IndexFunc(cfg.Prompts, func(p Prompt) bool { return p.Title == title })
+	if i < 0 {
+		return fmt.Errorf("no prompt titled %q", title)
+	}
+
+	previous := cfg.Prompts
+	cfg.Prompts = slices.Clone(cfg.Prompts)
+	cfg.Prompts[i].Pinned = pinned
+	if err := saveConfig(cfg); err != nil {
+		cfg.Prompts = previous
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// useTempConfigDir points the config directory at a fresh temporary one,
+// so saveConfig doesn't touch the real settings
+func useTempConfigDir(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+}
+
+func TestMergePrompts(t *testing.T) {
+	a := Prompt{Title: "A", Text: "a"}
+	b := Prompt{Title: "B", Text: "b"}
+	newA := Prompt{Title: "A", Text: "new a"}
+	c := Prompt{Title: "C", Text: "c"}
+
+	tests := []struct {
+		name     string
+		existing []Prompt
+		imported []Prompt
+		replace  bool
+		want     []Prompt
+	}{
+		{"new titles are appended", []Prompt{a}, []Prompt{b, c}, false, []Prompt{a, b, c}},
+		{"duplicate title overwrites in place", []Prompt{a, b}, []Prompt{newA}, false, []Prompt{newA, b}},
+		{"overwrite and append together", []Prompt{a, b}, []Prompt{c, newA}, false, []Prompt{newA, b, c}},
+		{"nothing imported", []Prompt{a, b}, nil, false, []Prompt{a, b}},
+		{"into no prompts", nil, []Prompt{a}, false, []Prompt{a}},
+		{"replace drops existing", []Prompt{a, b}, []Prompt{c}, true, []Prompt{c}},
+		{"replace with nothing", []Prompt{a}, nil, true, []Prompt{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := slices.Clone(tt.existing)
+			got := mergePrompts(tt.existing, tt.imported, tt.replace)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("mergePrompts() = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(tt.existing, existing) {
+				t.Errorf("mergePrompts() changed existing to %v", tt.existing)
+			}
+		})
+	}
+}
+
+func TestReadPromptLibrary(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    []string // texts, in order
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			file: `{"version": 1, "prompts": [{"title": "A", "text": "a"}, {"title": "B", "text": "b"}]}`,
+			want: []string{"a", "b"},
+		},
+		{
+			name: "later duplicate title is skipped",
+			file: `{"version": 1, "prompts": [{"title": "A", "text": "first"}, {"title": "A", "text": "second"}, {"title": "B", "text": "b"}]}`,
+			want: []string{"first", "b"},
+		},
+		{
+			name:    "missing text",
+			file:    `{"version": 1, "prompts": [{"title": "A", "text": " "}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing title",
+			file:    `{"version": 1, "prompts": [{"text": "a"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "newer schema",
+			file:    `{"version": 99, "prompts": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "not JSON",
+			file:    `prompts`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "prompts.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			prompts, err := readPromptLibrary(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("readPromptLibrary() = %v, want an error", prompts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readPromptLibrary() failed: %v", err)
+			}
+			var texts []string
+			for _, p := range prompts {
+				texts = append(texts, p.Text)
+			}
+			if !slices.Equal(texts, tt.want) {
+				t.Errorf("readPromptLibrary() texts = %v, want %v", texts, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportPromptsMergesDuplicates(t *testing.T) {
+	useTempConfigDir(t)
+	cfg := defaultConfig()
+	cfg.Prompts = []Prompt{{Title: "A", Text: "old"}, {Title: "B", Text: "b"}}
+
+	path := filepath.Join(t.TempDir(), "prompts.json")
+	if err := exportPrompts(path, []Prompt{{Title: "A", Text: "new"}, {Title: "C", Text: "c"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := importPrompts(cfg, path, false); err != nil {
+		t.Fatalf("importPrompts() failed: %v", err)
+	}
+	want := []Prompt{{Title: "A", Text: "new"}, {Title: "B", Text: "b"}, {Title: "C", Text: "c"}}
+	if !slices.Equal(cfg.Prompts, want) {
+		t.Errorf("prompts = %v, want %v", cfg.Prompts, want)
+	}
+}
+
+func TestMovePromptKeepsSelection(t *testing.T) {
+	useTempConfigDir(t)
+	titles := []string{"A", "B", "C", "D"}
+	selectedTitle := func(cfg *Config) string {
+		if cfg.SelectedIndex == 0 {
+			return "default"
+		}
+		return cfg.Prompts[cfg.SelectedIndex-1].Title
+	}
+
+	// Every move with every prompt selected, the default prompt included
+	for selected := 0; selected <= len(titles); selected++ {
+		for _, title := range titles {
+			for position := 1; position <= len(titles); position++ {
+				cfg := defaultConfig()
+				for _, title := range titles {
+					cfg.Prompts = append(cfg.Prompts, Prompt{Title: title, Text: title})
+				}
+				cfg.SelectedIndex = selected
+				want := selectedTitle(cfg)
+
+				if err := movePrompt(cfg, title, position); err != nil {
+					t.Fatalf("movePrompt(%q, %d) failed: %v", title, position, err)
+				}
+				if cfg.Prompts[position-1].Title != title {
+					t.Errorf("movePrompt(%q, %d) put %q there", title, position, cfg.Prompts[position-1].Title)
+				}
+				if got := selectedTitle(cfg); got != want {
+					t.Errorf("with %q selected, movePrompt(%q, %d) selected %q", want, title, position, got)
+				}
+			}
+		}
+	}
+}
+
+func TestMovePromptInvalid(t *testing.T) {
+	useTempConfigDir(t)
+	cfg := defaultConfig()
+	cfg.Prompts = []Prompt{{Title: "A", Text: "a"}, {Title: "B", Text: "b"}}
+	cfg.SelectedIndex = 2
+	for _, tt := range []struct {
+		title    string
+		position int
+	}{{"Missing", 1}, {"A", 0}, {"A", 3}} {
+		if err := movePrompt(cfg, tt.title, tt.position); err == nil {
+			t.Errorf("movePrompt(%q, %d) succeeded, want an error", tt.title, tt.position)
+		}
+	}
+	if cfg.Prompts[0].Title != "A" || cfg.SelectedIndex != 2 {
+		t.Errorf("failed moves changed the config: %v, selected %d", cfg.Prompts, cfg.SelectedIndex)
+	}
+}
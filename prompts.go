@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultPromptTitle identifies the built-in prompt in the history
+const defaultPromptTitle = "Default"
+
+const defaultPromptText = `Translate this text to English and fix any grammar or spelling errors.
+If the text is already in English, just correct any errors.
+If it's in Armenian (including transliterated Armenian), translate to English.
+Return only the corrected/translated text without any additional comments or explanations:`
+
+// defaultTargetLang is used when a prompt leaves TargetLang empty
+const defaultTargetLang = "en"
+
+const toneInstruction = "\n\nUse %s address appropriate for the %s language."
+
+// Tones a prompt can ask for; an empty Tone leaves the choice to the model
+const (
+	toneFormal   = "formal"
+	toneInformal = "informal"
+)
+
+const detectLanguagePrompt = `Identify the language of this text. Respond with only its ISO 639-1 code:`
+
+// Prompt is an instruction sent to the model along with the selected text.
+// A non-empty Hotkey runs the prompt directly, bypassing the selected one.
+//
+// Text is a text/template rendered with PromptContext, so it can refer to
+// {{.TargetLang}}, {{.Date}} or {{.Vars.name}} and, when AutoDetect is
+// set, {{.SourceLang}}.
+type Prompt struct {
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+	Hotkey     string `json:"hotkey,omitempty"`
+	TargetLang string `json:"target_lang,omitempty"`
+	AutoDetect bool   `json:"auto_detect,omitempty"`
+	Tone       string `json:"tone,omitempty"`
+
+	// TimeoutSecs overrides Config.TimeoutSecs when non-zero
+	TimeoutSecs int `json:"timeout_secs,omitempty"`
+
+	// NextPromptTitle names a prompt that receives this one's output
+	NextPromptTitle string `json:"next_prompt_title,omitempty"`
+
+	// Pinned lists the prompt first in the tray and under Favorites
+	Pinned bool `json:"pinned,omitempty"`
+}
+
+// PromptContext holds the values available to prompt templates
+type PromptContext struct {
+	Date       string // YYYY-MM-DD
+	Time       string // HH:MM
+	ActiveApp  string // title of the focused window, empty when unknown
+	SourceLang string
+	TargetLang string
+	WordCount  int
+	Vars       map[string]string // Config.TemplateVars
+}
+
+var isoCodePattern = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// renderPrompt expands the prompt template for text, first asking the
+// model for the source language when the prompt has AutoDetect set
+func (t *TranslatorApp) renderPrompt(ctx context.Context, p Prompt, text string) (string, error) {
+	rendered, _, err := t.renderPromptContext(ctx, p, text)
+	return rendered, err
+}
+
+// renderPromptContext is renderPrompt, also returning the values the
+// template was rendered with
+func (t *TranslatorApp) renderPromptContext(ctx context.Context, p Prompt, text string) (string, PromptContext, error) {
+	// Parse errors are reported before the language is detected
+	_, err := parsePrompt(p)
+	if err != nil {
+		return "", PromptContext{}, err
+	}
+
+	now := time.Now()
+	data := PromptContext{
+		Date:       now.Format(time.DateOnly),
+		Time:       now.Format("15:04"),
+		ActiveApp:  activeApp(),
+		TargetLang: p.TargetLang,
+		WordCount:  countWords(text),
+		Vars:       t.currentConfig().TemplateVars,
+	}
+	if data.TargetLang == "" {
+		data.TargetLang = defaultTargetLang
+	}
+	if _, dryRun := dryRunFrom(ctx); p.AutoDetect && dryRun {
+		log.Println("   Dry run: the source language isn't detected")
+	} else if p.AutoDetect {
+		if data.SourceLang, err = t.detectLanguage(ctx, text); err != nil {
+			return "", data, err
+		}
+		log.Printf("   Detected language: %s", data.SourceLang)
+	}
+
+	rendered, err := executePrompt(p, t.prompts(), data, nil)
+	if err != nil {
+		return "", data, fmt.Errorf("failed to render prompt %q: %w", p.Title, err)
+	}
+	if p.Tone != "" {
+		rendered += fmt.Sprintf(toneInstruction, p.Tone, data.TargetLang)
+	}
+	return rendered, data, nil
+}
+
+// parsePrompt parses the template of p
+func parsePrompt(p Prompt) (*template.Template, error) {
+	tmpl, err := template.New(p.Title).Option("missingkey=zero").Funcs(includeStub).Parse(p.Text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template %q: %w", p.Title, err)
+	}
+	return tmpl, nil
+}
+
+// checkPromptTemplates reports the first prompt whose template doesn't
+// parse, includes a missing prompt or itself, or whose tone is unknown
+func checkPromptTemplates(cfg *Config) error {
+	for _, p := range cfg.Prompts {
+		if _, err := parsePrompt(p); err != nil {
+			return err
+		}
+		if p.Tone != "" && p.Tone != toneFormal && p.Tone != toneInformal {
+			return fmt.Errorf("prompt %q has tone %q, use %q or %q", p.Title, p.Tone, toneFormal, toneInformal)
+		}
+	}
+	return checkIncludes(append([]Prompt{defaultPrompt}, cfg.Prompts...))
+}
+
+// detectLanguage asks the model for the ISO 639-1 code of text. Codes
+// are kept in the translation cache, so repeated texts aren't asked about
+// again.
+func (t *TranslatorApp) detectLanguage(ctx context.Context, text string) (string, error) {
+	key := cacheKey(t.currentConfig().Model, detectLanguagePrompt, "", text)
+	if code, ok := t.cache.get(key); ok {
+		return code, nil
+	}
+	var code string
+	var err error
+	translator, _ := t.backend()
+	if detector, ok := translator.(LanguageDetector); ok {
+		code, err = detector.DetectLanguage(ctx, text)
+	} else {
+		code, err = translator.Translate(ctx, detectLanguagePrompt, text)
+	}
+	if err != nil {
+		return "", fmt.Errorf("language detection failed: %w", err)
+	}
+
+	code = strings.ToLower(strings.Trim(strings.TrimSpace(code), ".`\"'"))
+	if !isoCodePattern.MatchString(code) {
+		return "", fmt.Errorf("language detection returned %q instead of an ISO code", code)
+	}
+	t.cache.put(key, code)
+	return code, nil
+}
+
+var defaultPrompt = Prompt{Title: defaultPromptTitle, Text: defaultPromptText}
+
+// prompts returns the built-in default prompt followed by the user's prompts
+func (t *TranslatorApp) prompts() []Prompt {
+	return append([]Prompt{defaultPrompt}, t.currentConfig().Prompts...)
+}
+
+// selectedIndex returns the index of the prompt run by the global hotkey,
+// falling back to the default when the saved index is out of range
+func (t *TranslatorApp) selectedIndex() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.config.SelectedIndex < 0 || t.config.SelectedIndex > len(t.config.Prompts) {
+		return 0
+	}
+	return t.config.SelectedIndex
+}
+
+// selectedPrompt returns the prompt run by the global hotkey
+func (t *TranslatorApp) selectedPrompt() Prompt {
+	return t.prompts()[t.selectedIndex()]
+}
+
+// findPrompt returns the prompt with the given title
+func (t *TranslatorApp) findPrompt(title string) (Prompt, bool) {
+	for _, p := range t.prompts() {
+		if p.Title == title {
+			return p, true
+		}
+	}
+	return Prompt{}, false
+}
+
+// selectPrompt makes the prompt at index i the one run by the global hotkey
+func (t *TranslatorApp) selectPrompt(i int) {
+	err := t.updateConfig(func(cfg *Config) error {
+		cfg.SelectedIndex = i
+		return saveConfig(cfg)
+	})
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to save config: %v", err))
+	}
+}
+
+// renamePrompt renames the user's prompt titled old and the references to
+// it in other prompts, their includes, app profiles and the prompt
+// history, then saves cfg
+func renamePrompt(cfg *Config, old, title string) error {
+	i := slices.IndexFunc(cfg.Prompts, func(p Prompt) bool { return p.Title == old })
+	if i < 0 {
+		return fmt.Errorf("no prompt titled %q", old)
+	}
+	if title == "" || title == defaultPromptTitle || slices.ContainsFunc(cfg.Prompts, func(p Prompt) bool { return p.Title == title }) {
+		return fmt.Errorf("a prompt can't be titled %q", title)
+	}
+
+	prompts := slices.Clone(cfg.Prompts)
+	for j := range prompts {
+		if j == i {
+			prompts[j].Title = title
+		}
+		if prompts[j].NextPromptTitle == old {
+			prompts[j].NextPromptTitle = title
+		}
+		prompts[j].Text = strings.ReplaceAll(prompts[j].Text,
+			includeFunc+" "+strconv.Quote(old), includeFunc+" "+strconv.Quote(title))
+	}
+	profiles := slices.Clone(cfg.AppProfiles)
+	for j := range profiles {
+		if profiles[j].PromptTitle == old {
+			profiles[j].PromptTitle = title
+		}
+	}
+	versions := slices.Clone(cfg.PromptHistory)
+	for j := range versions {
+		if versions[j].Title == old {
+			versions[j].Title = title
+		}
+	}
+
+	previous := *cfg
+	cfg.Prompts, cfg.AppProfiles, cfg.PromptHistory = prompts, profiles, versions
+	if err := saveConfig(cfg); err != nil {
+		cfg.Prompts, cfg.AppProfiles, cfg.PromptHistory = previous.Prompts, previous.AppProfiles, previous.PromptHistory
+		return err
+	}
+	return nil
+}
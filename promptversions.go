@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxPromptVersions is how many earlier versions are kept per prompt
+const maxPromptVersions = 20
+
+// PromptVersion is the text a prompt had before it was changed
+type PromptVersion struct {
+	Title   string    `json:"title"`
+	Text    string    `json:"text"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// savedPrompts returns the prompts in the settings.json in dir, or nil
+// when it can't be read
+func savedPrompts(dir string) []Prompt {
+	data, err := os.ReadFile(filepath.Join(dir, configFileName))
+	if err != nil {
+		return nil
+	}
+	var saved struct {
+		Prompts []Prompt `json:"prompts"`
+	}
+	if json.Unmarshal(data, &saved) != nil {
+		return nil
+	}
+	return saved.Prompts
+}
+
+// recordPromptVersions adds the previous text of every prompt in previous
+// whose text in cfg is different to Config.PromptHistory, keeping the
+// newest maxPromptVersions of each prompt
+func recordPromptVersions(cfg *Config, previous []Prompt, now time.Time) {
+	for _, old := range previous {
+		i := slices.IndexFunc(cfg.Prompts, func(p Prompt) bool { return p.Title == old.Title })
+		if i < 0 || cfg.Prompts[i].Text == old.Text {
+			continue
+		}
+		cfg.PromptHistory = append(cfg.PromptHistory, PromptVersion{Title: old.Title, Text: old.Text, SavedAt: now})
+
+		count := 0
+		for j := len(cfg.PromptHistory) - 1; j >= 0; j-- {
+			if cfg.PromptHistory[j].Title != old.Title {
+				continue
+			}
+			if count++; count > maxPromptVersions {
+				cfg.PromptHistory = slices.Delete(cfg.PromptHistory, j, j+1)
+			}
+		}
+	}
+}
+
+// promptVersions returns the earlier versions of the prompt with the given
+// title, newest first
+func promptVersions(cfg *Config, title string) []PromptVersion {
+	var versions []PromptVersion
+	for _, v := range slices.Backward(cfg.PromptHistory) {
+		if v.Title == title {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// colorDiff renders ops for a terminal, with deletions in red and
+// insertions in green
+func colorDiff(ops []diffOp) string {
+	words := make([]string, len(ops))
+	for i, op := range ops {
+		switch op.Kind {
+		case '-':
+			words[i] = "\x1b[31m" + op.Word + "\x1b[0m"
+		case '+':
+			words[i] = "\x1b[32m" + op.Word + "\x1b[0m"
+		default:
+			words[i] = op.Word
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
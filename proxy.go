@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// parseProxyURL validates an http://, https:// or socks5:// proxy URL.
+// net/http dials SOCKS5 proxies itself, with the user and password of
+// the URL if it has them.
+func parseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http_proxy %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid http_proxy %q: scheme must be http, https or socks5", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid http_proxy %q: missing host", raw)
+	}
+	return u, nil
+}
+
+// proxyFunc routes requests through Config.HTTPProxy, except for hosts in
+// Config.NoProxy. Without HTTPProxy the HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY environment variables apply. HTTPS requests are tunnelled with
+// CONNECT by net/http.
+func proxyFunc(cfg *Config) (func(*http.Request) (*url.URL, error), error) {
+	if cfg.HTTPProxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	if _, err := parseProxyURL(cfg.HTTPProxy); err != nil {
+		return nil, err
+	}
+
+	proxy := (&httpproxy.Config{
+		HTTPProxy:  cfg.HTTPProxy,
+		HTTPSProxy: cfg.HTTPProxy,
+		NoProxy:    cfg.NoProxy,
+	}).ProxyFunc()
+	return func(r *http.Request) (*url.URL, error) {
+		return proxy(r.URL)
+	}, nil
+}
+
+// newHTTPClient returns the client used for API calls, honouring the
+// proxy settings and tracing requests in otel builds
+func newHTTPClient(cfg *Config) (*http.Client, error) {
+	proxy, err := proxyFunc(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	return &http.Client{Transport: tracedTransport(transport)}, nil
+}
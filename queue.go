@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// translationJob is a hotkey-triggered translation waiting in the queue
+type translationJob struct {
+	name   string
+	run    func(ctx context.Context)
+	ctx    context.Context
+	cancel context.CancelFunc
+	queued time.Time
+}
+
+// jobQueue runs translations one at a time so that rapid hotkey presses
+// don't fight over the clipboard. Jobs waiting longer than ttl are dropped.
+type jobQueue struct {
+	jobs chan *translationJob
+	ttl  time.Duration
+
+	mu       sync.Mutex
+	active   map[*translationJob]struct{} // queued and running jobs
+	onChange func(pending int)
+}
+
+func newJobQueue(size int, ttl time.Duration) *jobQueue {
+	return &jobQueue{
+		jobs:   make(chan *translationJob, max(size, 1)),
+		ttl:    ttl,
+		active: make(map[*translationJob]struct{}),
+	}
+}
+
+// run processes jobs until the queue is closed
+func (q *jobQueue) run() {
+	for job := range q.jobs {
+		switch {
+		case job.ctx.Err() != nil:
+			// Cancelled while waiting
+		case q.ttl > 0 && time.Since(job.queued) > q.ttl:
+			slog.Warn(fmt.Sprintf("⚠️  Dropped %q after waiting %s in the queue", job.name, time.Since(job.queued).Round(time.Second)))
+		default:
+			job.run(job.ctx)
+		}
+		q.finish(job)
+	}
+}
+
+// enqueue adds a job, or drops it with a warning when the queue is full
+func (q *jobQueue) enqueue(name string, run func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &translationJob{name: name, run: run, ctx: ctx, cancel: cancel, queued: time.Now()}
+
+	q.mu.Lock()
+	select {
+	case q.jobs <- job:
+		q.active[job] = struct{}{}
+	default:
+		q.mu.Unlock()
+		cancel()
+		slog.Warn(fmt.Sprintf("⚠️  Queue is full, ignoring %q", name))
+		return
+	}
+	pending := len(q.active)
+	q.mu.Unlock()
+
+	if pending > 1 {
+		log.Printf("   Queued %q, %d pending", name, pending)
+	}
+	q.changed(pending)
+}
+
+// pending returns the number of running and queued jobs
+func (q *jobQueue) pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.active)
+}
+
+// cancelAll cancels the running job and every queued one
+func (q *jobQueue) cancelAll() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for job := range q.active {
+		job.cancel()
+	}
+	return len(q.active)
+}
+
+func (q *jobQueue) finish(job *translationJob) {
+	job.cancel()
+	q.mu.Lock()
+	delete(q.active, job)
+	pending := len(q.active)
+	q.mu.Unlock()
+	q.changed(pending)
+}
+
+func (q *jobQueue) changed(pending int) {
+	queueDepth.Set(float64(pending))
+	q.mu.Lock()
+	onChange := q.onChange
+	q.mu.Unlock()
+	if onChange != nil {
+		onChange(pending)
+	}
+}
+
+// setOnChange registers fn to be called with the number of pending jobs
+// whenever it changes
+func (q *jobQueue) setOnChange(fn func(pending int)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.onChange = fn
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// rateWindow is the rolling window Config.MaxRequestsPerMin and
+// Config.MaxTokensPerMin are counted over
+const rateWindow = time.Minute
+
+// errRateLimited is returned when a request would wait past its deadline
+var errRateLimited = errors.New("rate limited")
+
+// rateEvent is a request sent within the window
+type rateEvent struct {
+	at     time.Time
+	tokens int
+}
+
+// rateLimiter keeps requests under a number of requests and estimated
+// tokens per rolling minute, so the free tier quota isn't exceeded. A nil
+// limiter allows everything.
+type rateLimiter struct {
+	maxRequests int // 0 means no limit
+	maxTokens   int
+
+	mu     sync.Mutex
+	events []rateEvent
+	onWait func(wait time.Duration) // shows the wait, and 0 when it's over
+}
+
+// newRateLimiter returns nil when neither limit is set
+func newRateLimiter(maxRequests, maxTokens int) *rateLimiter {
+	if maxRequests <= 0 && maxTokens <= 0 {
+		return nil
+	}
+	return &rateLimiter{maxRequests: maxRequests, maxTokens: maxTokens}
+}
+
+// estimateTokens guesses the tokens of text as one per four characters
+func estimateTokens(text string) int {
+	return utf8.RuneCountInString(text)/4 + 1
+}
+
+// setOnWait registers a function told about waits, e.g. to show them in
+// the tray
+func (l *rateLimiter) setOnWait(fn func(wait time.Duration)) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.onWait = fn
+	l.mu.Unlock()
+}
+
+// delay returns how long a request of the given tokens has to wait, and
+// records it when it doesn't have to and reserve is set
+func (l *rateLimiter) delay(tokens int, reserve bool) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	kept := l.events[:0]
+	used := 0
+	for _, e := range l.events {
+		if now.Sub(e.at) < rateWindow {
+			kept = append(kept, e)
+			used += e.tokens
+		}
+	}
+	l.events = kept
+
+	// Wait for the oldest requests to leave the window until there is
+	// room. A request bigger than the whole budget goes through alone.
+	var wait time.Duration
+	count := len(l.events)
+	for i := 0; i < len(l.events); i++ {
+		overRequests := l.maxRequests > 0 && count >= l.maxRequests
+		overTokens := l.maxTokens > 0 && used+tokens > l.maxTokens
+		if !overRequests && !overTokens {
+			break
+		}
+		wait = l.events[i].at.Add(rateWindow).Sub(now)
+		count--
+		used -= l.events[i].tokens
+	}
+	if wait <= 0 && reserve {
+		l.events = append(l.events, rateEvent{at: now, tokens: tokens})
+	}
+	return wait
+}
+
+// wait blocks until a request of the given tokens fits in the limits and
+// records it. It fails straight away when ctx would expire first.
+func (l *rateLimiter) wait(ctx context.Context, tokens int) error {
+	return l.waitFor(ctx, tokens, true)
+}
+
+// waitForRoom blocks until a request of the given tokens would fit,
+// without recording it, so callers can wait before starting their
+// translation deadline
+func (l *rateLimiter) waitForRoom(ctx context.Context, tokens int) error {
+	return l.waitFor(ctx, tokens, false)
+}
+
+func (l *rateLimiter) waitFor(ctx context.Context, tokens int, reserve bool) error {
+	if l == nil {
+		return nil
+	}
+	waited := false
+	for {
+		wait := l.delay(tokens, reserve)
+		if wait <= 0 {
+			if waited {
+				l.notify(0)
+			}
+			return nil
+		}
+		slog.Debug("rate limit reached", "wait", wait, "tokens", tokens)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return fmt.Errorf("%w, try again in %s", errRateLimited, wait.Round(time.Second))
+		}
+
+		log.Printf("⏳ Rate limited — retrying in %s", wait.Round(time.Second))
+		l.notify(wait)
+		waited = true
+		select {
+		case <-ctx.Done():
+			l.notify(0)
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (l *rateLimiter) notify(wait time.Duration) {
+	l.mu.Lock()
+	fn := l.onWait
+	l.mu.Unlock()
+	if fn != nil {
+		fn(wait)
+	}
+}
@@ -0,0 +1,90 @@
+//go:build !headless
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"log/slog"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/go-vgo/robotgo"
+)
+
+// minRegionSize rejects regions too small to hold any text, which usually
+// means the hotkey was pressed twice without moving the mouse
+const minRegionSize = 8
+
+// onRegionHotkey marks a corner of the screen region to OCR. The first
+// press records the mouse position; the second captures the rectangle
+// between both positions and translates the text found in it.
+func (t *TranslatorApp) onRegionHotkey() {
+	x, y := robotgo.Location()
+
+	t.mu.Lock()
+	start := t.regionStart
+	if start == nil {
+		t.regionStart = &image.Point{X: x, Y: y}
+		t.mu.Unlock()
+		log.Println("📷 First corner set - move to the opposite corner and press the OCR hotkey again")
+		return
+	}
+	t.regionStart = nil
+	t.mu.Unlock()
+
+	region := image.Rect(start.X, start.Y, x, y)
+	if region.Dx() < minRegionSize || region.Dy() < minRegionSize {
+		slog.Warn(fmt.Sprintf("⚠️  Region %v is too small, start again", region))
+		return
+	}
+	t.queue.enqueue("OCR region", func(ctx context.Context) { t.translateRegion(ctx, region) })
+}
+
+// translateRegion captures region, extracts its text and runs it through
+// the selected prompt as if it had been selected
+func (t *TranslatorApp) translateRegion(ctx context.Context, region image.Rectangle) {
+	log.Printf("▶ Capturing region %v...", region)
+	img, err := robotgo.CaptureImg(region.Min.X, region.Min.Y, region.Dx(), region.Dy())
+	if err != nil {
+		slog.Error(fmt.Sprintf("❌ Failed to capture screen: %v", err))
+		return
+	}
+	text, err := t.ocrImage(ctx, img)
+	if err != nil {
+		slog.Error(fmt.Sprintf("❌ %v", err))
+		return
+	}
+	if strings.TrimSpace(text) == "" {
+		slog.Warn("⚠️  No text found in the region")
+		return
+	}
+
+	previousClipboard, err := clipboard.ReadAll()
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to read current clipboard: %v", err))
+		previousClipboard = ""
+	}
+	t.translateAndPaste(ctx, t.selectedPrompt(), text, previousClipboard, false)
+}
+
+// ocrImage extracts the text of img with the configured OCR provider
+func (t *TranslatorApp) ocrImage(ctx context.Context, img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	cfg := t.currentConfig()
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout(cfg, Prompt{}))
+	defer cancel()
+	text, err := extractText(ctx, cfg, buf.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("OCR failed: %w", err)
+	}
+	return text, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// maxBackoff truncates the exponential wait between attempts
+const maxBackoff = 8 * time.Second
+
+// Bounds for Config.TimeoutSecs and Prompt.TimeoutSecs
+const (
+	minTimeoutSecs = 5
+	maxTimeoutSecs = 300
+)
+
+// requestTimeout bounds a single API attempt: the prompt's own timeout,
+// or the global one when the prompt doesn't set it
+func requestTimeout(cfg *Config, p Prompt) time.Duration {
+	secs := cfg.TimeoutSecs
+	if p.TimeoutSecs != 0 {
+		secs = p.TimeoutSecs
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// checkTimeouts rejects timeouts outside minTimeoutSecs..maxTimeoutSecs
+func checkTimeouts(cfg *Config) error {
+	if cfg.TimeoutSecs < minTimeoutSecs || cfg.TimeoutSecs > maxTimeoutSecs {
+		return fmt.Errorf("timeout_secs must be between %d and %d, got %d", minTimeoutSecs, maxTimeoutSecs, cfg.TimeoutSecs)
+	}
+	if cfg.ShutdownTimeoutSecs < 0 || cfg.ShutdownTimeoutSecs > maxTimeoutSecs {
+		return fmt.Errorf("shutdown_timeout_secs must be between 0 and %d, got %d", maxTimeoutSecs, cfg.ShutdownTimeoutSecs)
+	}
+	for _, p := range cfg.Prompts {
+		if p.TimeoutSecs != 0 && (p.TimeoutSecs < minTimeoutSecs || p.TimeoutSecs > maxTimeoutSecs) {
+			return fmt.Errorf("prompt %q has timeout_secs %d, use 0 or %d to %d", p.Title, p.TimeoutSecs, minTimeoutSecs, maxTimeoutSecs)
+		}
+	}
+	return nil
+}
+
+// backoff returns the wait before the given retry (1-based)
+func backoff(base time.Duration, retry int) time.Duration {
+	wait := base << (retry - 1)
+	if wait > maxBackoff || wait <= 0 {
+		return maxBackoff
+	}
+	return wait
+}
+
+// translationTimeout is the overall deadline for a translation with p,
+// leaving room for every attempt and the waits between them
+func translationTimeout(cfg *Config, p Prompt) time.Duration {
+	attempt := requestTimeout(cfg, p)
+	total := attempt
+	for retry := 1; retry < cfg.MaxRetries; retry++ {
+		total += backoff(cfg.RetryBackoffBase.Std(), retry) + attempt
+	}
+	return total
+}
+
+// isRetryable reports whether err is a transient network or server error.
+// Auth errors (401/403) and other client errors are returned immediately.
+func isRetryable(err error) bool {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Code == http.StatusServiceUnavailable
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// isRateLimited reports whether err is a 429 from the API
+func isRateLimited(err error) bool {
+	var apiErr genai.APIError
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusTooManyRequests
+}
+
+// errorCategory names the kind of a translation error for notifications
+func errorCategory(err error) string {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return "Auth error"
+		case http.StatusTooManyRequests:
+			return "Rate limited"
+		}
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errRateLimited):
+		return "Rate limited"
+	case errors.Is(err, errDeepLQuota):
+		return "Quota exceeded"
+	case errors.As(err, &netErr), errors.Is(err, context.DeadlineExceeded):
+		return "Network error"
+	}
+	return "Translation failed"
+}
+
+// withRetry calls fn up to attempts times, backing off exponentially from
+// base between transient failures
+func withRetry(ctx context.Context, attempts int, base time.Duration, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= attempts || !isRetryable(err) {
+			return err
+		}
+
+		wait := backoff(base, attempt)
+		log.Printf("🔁 Attempt %d/%d failed: %v - retrying in %s", attempt, attempts, err, wait)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// sanitiseText turns clipboard text into clean UTF-8. Windows sometimes
+// hands over UTF-16LE, recognised by its byte order mark or by a NUL after
+// every ASCII character, or a legacy code page, read as Windows-1252 when
+// the text isn't valid UTF-8. The result is NFC normalised and stripped
+// of control characters other than line breaks and tabs.
+func sanitiseText(raw string) string {
+	text := raw
+	switch {
+	case strings.HasPrefix(raw, "\xef\xbb\xbf"):
+		text = raw[3:]
+	case strings.HasPrefix(raw, "\xff\xfe"):
+		text = decodeUTF16(raw[2:], false)
+	case strings.HasPrefix(raw, "\xfe\xff"):
+		text = decodeUTF16(raw[2:], true)
+	case looksLikeUTF16LE(raw):
+		text = decodeUTF16(raw, false)
+	case !utf8.ValidString(raw):
+		text = decodeLegacy(raw)
+	}
+
+	text = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) || r == '\ufeff' {
+			return -1
+		}
+		return r
+	}, text)
+	return norm.NFC.String(text)
+}
+
+// decodeLegacy reads text that isn't valid UTF-8 as Windows-1252, the
+// usual legacy code page, which agrees with Latin-1 on its printable
+// characters
+func decodeLegacy(raw string) string {
+	decoded, err := charmap.Windows1252.NewDecoder().String(raw)
+	if err != nil {
+		return raw
+	}
+	return decoded
+}
+
+// looksLikeUTF16LE reports whether raw has a NUL in most odd positions,
+// which is how ASCII text reads in UTF-16LE
+func looksLikeUTF16LE(raw string) bool {
+	if len(raw) < 2 || len(raw)%2 != 0 {
+		return false
+	}
+	nuls := 0
+	for i := 1; i < len(raw); i += 2 {
+		if raw[i] == 0 {
+			nuls++
+		}
+	}
+	return nuls*2 > len(raw)/2
+}
+
+// decodeUTF16 decodes UTF-16 bytes, big or little endian. A trailing odd
+// byte is dropped.
+func decodeUTF16(raw string, bigEndian bool) string {
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		lo, hi := uint16(raw[2*i]), uint16(raw[2*i+1])
+		if bigEndian {
+			lo, hi = hi, lo
+		}
+		units[i] = hi<<8 | lo
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestSanitiseText(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"plain UTF-8", "Hello, world", "Hello, world"},
+		{"UTF-8 with BOM", "\xef\xbb\xbfHello", "Hello"},
+		{"UTF-16LE with BOM", "\xff\xfeH\x00i\x00", "Hi"},
+		{"UTF-16LE without BOM", "H\x00e\x00l\x00l\x00o\x00", "Hello"},
+		{"UTF-16LE non-ASCII with BOM", "\xff\xfe\x32\x05\x61\x05\x80\x05\x87\x05", "Բարև"},
+		{"UTF-16LE surrogate pair", "\xff\xfe\x3d\xd8\x4b\xdc", "👋"},
+		{"UTF-16LE line break", "\xff\xfea\x00\r\x00\n\x00b\x00", "a\r\nb"},
+		{"UTF-16BE with BOM", "\xfe\xff\x00H\x00i", "Hi"},
+		{"Windows-1252", "caf\xe9 \x93ok\x94 \x80", "café “ok” €"},
+		{"control characters dropped", "a\x00b\x07c\td\n", "abc\td\n"},
+		{"zero width no-break space dropped", "a\ufeffb", "ab"},
+		{"NFC normalised", "cafe\u0301", "caf\u00e9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitiseText(tt.raw); got != tt.want {
+				t.Errorf("sanitiseText(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLooksLikeUTF16LE(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{"H\x00i\x00", true},
+		{"Hi", false},
+		{"H\x00i", false},
+		{"", false},
+		{"ab\x00\x00cdef", false},
+	}
+	for _, tt := range tests {
+		if got := looksLikeUTF16LE(tt.raw); got != tt.want {
+			t.Errorf("looksLikeUTF16LE(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeTextMatchesClipboard(t *testing.T) {
+	// Files and clipboard text that aren't UTF-8 are read the same way
+	raw := "\x93Caf\xe9\x94 \x80"
+	if got, want := decodeText([]byte(raw)), sanitiseText(raw); got != want {
+		t.Errorf("decodeText() = %q, sanitiseText() = %q", got, want)
+	}
+	if got := decodeText([]byte("\ufeffBOM")); got != "BOM" {
+		t.Errorf("decodeText() kept the BOM: %q", got)
+	}
+}
@@ -0,0 +1,110 @@
+//go:build !headless
+
+package main
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// axSelectedText reads the selection of the focused element of the
+// frontmost app through the macOS accessibility API
+const axSelectedText = `tell application "System Events"
+	set focused to value of attribute "AXFocusedUIElement" of (first application process whose frontmost is true)
+	return value of attribute "AXSelectedText" of focused
+end tell`
+
+// uiaSelectedText reads the selection of the focused element through
+// Windows UI Automation
+const uiaSelectedText = `Add-Type -AssemblyName UIAutomationClient, UIAutomationTypes
+$focused = [System.Windows.Automation.AutomationElement]::FocusedElement
+$pattern = $null
+if ($focused -and $focused.TryGetCurrentPattern([System.Windows.Automation.TextPattern]::Pattern, [ref]$pattern)) {
+	[Console]::OutputEncoding = [Text.Encoding]::UTF8
+	($pattern.GetSelection() | ForEach-Object { $_.GetText(-1) }) -join ""
+}`
+
+// axSurroundingText prints the text of the focused element before and
+// after its selection, separated by a record separator
+const axSurroundingText = `tell application "System Events"
+	set focused to value of attribute "AXFocusedUIElement" of (first application process whose frontmost is true)
+	set fullText to value of attribute "AXValue" of focused
+	set {startPos, endPos} to value of attribute "AXSelectedTextRange" of focused
+end tell
+set before to ""
+set after to ""
+if startPos > 1 then set before to text 1 thru (startPos - 1) of fullText
+if endPos < (length of fullText) then set after to text (endPos + 1) thru -1 of fullText
+return before & (ASCII character 30) & after`
+
+// uiaSurroundingText does the same through Windows UI Automation
+const uiaSurroundingText = `Add-Type -AssemblyName UIAutomationClient, UIAutomationTypes
+$focused = [System.Windows.Automation.AutomationElement]::FocusedElement
+$pattern = $null
+if ($focused -and $focused.TryGetCurrentPattern([System.Windows.Automation.TextPattern]::Pattern, [ref]$pattern)) {
+	$selection = $pattern.GetSelection()
+	if ($selection.Length -gt 0) {
+		$before = $pattern.DocumentRange.Clone()
+		$before.MoveEndpointByRange([System.Windows.Automation.Text.TextPatternRangeEndpoint]::End, $selection[0], [System.Windows.Automation.Text.TextPatternRangeEndpoint]::Start)
+		$after = $pattern.DocumentRange.Clone()
+		$after.MoveEndpointByRange([System.Windows.Automation.Text.TextPatternRangeEndpoint]::Start, $selection[-1], [System.Windows.Automation.Text.TextPatternRangeEndpoint]::End)
+		[Console]::OutputEncoding = [Text.Encoding]::UTF8
+		$before.GetText(-1) + [char]30 + $after.GetText(-1)
+	}
+}`
+
+// readSurroundingText returns the text of the focused element before and
+// after the selection, through the accessibility API on macOS and
+// Windows. ok is false elsewhere or when the app doesn't expose it.
+func readSurroundingText() (before, after string, ok bool) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", axSurroundingText)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", uiaSurroundingText)
+	default:
+		return "", "", false
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", false
+	}
+	text := strings.TrimSuffix(strings.TrimSuffix(string(out), "\n"), "\r")
+	before, after, ok = strings.Cut(text, "\x1e")
+	return before, after, ok
+}
+
+// readSelection returns the selected text without pressing Ctrl+C or
+// touching the clipboard: through the accessibility API on macOS and
+// Windows, and from the PRIMARY selection on Linux. ok is false when the
+// selection can't be read this way or is empty.
+func readSelection() (_ string, ok bool) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", axSelectedText)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", uiaSelectedText)
+	default:
+		if path, err := exec.LookPath("xclip"); err == nil {
+			cmd = exec.Command(path, "-o", "-selection", "primary")
+		} else if path, err := exec.LookPath("xsel"); err == nil {
+			cmd = exec.Command(path, "--primary", "--output")
+		} else {
+			return "", false
+		}
+	}
+
+	out, err := cmd.Output()
+	if err != nil || strings.TrimSpace(string(out)) == "" {
+		return "", false
+	}
+	// The scripts end their output with a newline of their own
+	if runtime.GOOS != "linux" {
+		out = []byte(strings.TrimSuffix(strings.TrimSuffix(string(out), "\n"), "\r"))
+	}
+	return string(out), true
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type translateRequest struct {
+	Text        string `json:"text"`
+	PromptTitle string `json:"prompt_title"`
+}
+
+type translateResponse struct {
+	Result    string `json:"result"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// newServer exposes the translator over HTTP:
+//
+//	POST /translate  {"text":"...","prompt_title":"..."} -> {"result":"...","latency_ms":42}
+//	GET  /prompts    the configured prompts
+//	GET  /health     liveness check, no auth required
+func (t *TranslatorApp) newServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.Handle("GET /prompts", t.requireToken(http.HandlerFunc(t.handlePrompts)))
+	mux.Handle("POST /translate", t.requireToken(http.HandlerFunc(t.handleTranslate)))
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// requireToken rejects requests without "Authorization: Bearer <server_token>"
+func (t *TranslatorApp) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(t.currentConfig().ServerToken)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid or missing bearer token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (t *TranslatorApp) handlePrompts(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, t.prompts())
+}
+
+func (t *TranslatorApp) handleTranslate(w http.ResponseWriter, r *http.Request) {
+	var req translateRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON body: " + err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "text is required"})
+		return
+	}
+
+	prompt := t.selectedPrompt()
+	if req.PromptTitle != "" {
+		var ok bool
+		if prompt, ok = t.findPrompt(req.PromptTitle); !ok {
+			writeJSON(w, http.StatusNotFound, errorResponse{Error: "no prompt titled " + req.PromptTitle})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), translationTimeout(t.currentConfig(), prompt))
+	defer cancel()
+
+	start := time.Now()
+	result, err := t.translateText(ctx, prompt, req.Text)
+	if err != nil {
+		slog.Error(fmt.Sprintf("❌ API translation failed: %v", err))
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, translateResponse{Result: result, LatencyMs: time.Since(start).Milliseconds()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to write response: %v", err))
+	}
+}
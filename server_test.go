@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeTranslator answers every request with "translated: " and the text,
+// recording the prompts it was sent
+type fakeTranslator struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (f *fakeTranslator) Translate(_ context.Context, prompt, text string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.prompts = append(f.prompts, prompt)
+	return "translated: " + text, nil
+}
+
+func (f *fakeTranslator) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.prompts)
+}
+
+// newTestApp returns an app around translator with the default config, a
+// temporary history and no cache
+func newTestApp(t *testing.T, translator Translator) *TranslatorApp {
+	t.Helper()
+	useTempConfigDir(t)
+	history, err := openHistory(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { history.Close() })
+	return &TranslatorApp{
+		config:     defaultConfig(),
+		history:    history,
+		translator: translator,
+		usage:      &usageTracker{},
+		cache:      newTranslationCache(0, 0),
+	}
+}
+
+func TestServer(t *testing.T) {
+	app := newTestApp(t, &fakeTranslator{})
+	app.config.ServerToken = "secret"
+	app.config.Prompts = []Prompt{{Title: "Formal", Text: "Translate formally:"}}
+	srv := httptest.NewServer(app.newServer("").Handler)
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       string
+		wantStatus int
+		wantResult string
+	}{
+		{"health needs no token", http.MethodGet, "/health", "", "", http.StatusOK, ""},
+		{"no token", http.MethodPost, "/translate", "", `{"text":"hi"}`, http.StatusUnauthorized, ""},
+		{"wrong token", http.MethodPost, "/translate", "wrong", `{"text":"hi"}`, http.StatusUnauthorized, ""},
+		{"prompts without token", http.MethodGet, "/prompts", "", "", http.StatusUnauthorized, ""},
+		{"empty body", http.MethodPost, "/translate", "secret", "", http.StatusBadRequest, ""},
+		{"empty text", http.MethodPost, "/translate", "secret", `{"text":"  "}`, http.StatusBadRequest, ""},
+		{"unknown prompt", http.MethodPost, "/translate", "secret", `{"text":"hi","prompt_title":"Nope"}`, http.StatusNotFound, ""},
+		{"selected prompt", http.MethodPost, "/translate", "secret", `{"text":"hi"}`, http.StatusOK, "translated: hi"},
+		{"named prompt", http.MethodPost, "/translate", "secret", `{"text":"bye","prompt_title":"Formal"}`, http.StatusOK, "translated: bye"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantResult == "" {
+				return
+			}
+			var body translateResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Result != tt.wantResult {
+				t.Errorf("result = %q, want %q", body.Result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestServerRecordsHistory(t *testing.T) {
+	fake := &fakeTranslator{}
+	app := newTestApp(t, fake)
+	app.config.ServerToken = "secret"
+	srv := httptest.NewServer(app.newServer("").Handler)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/translate", strings.NewReader(`{"text":"Barev"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if fake.calls() != 1 {
+		t.Errorf("translator called %d times, want 1", fake.calls())
+	}
+	entries, err := app.history.Search("Barev", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Translated != "translated: Barev" {
+		t.Errorf("history = %+v, want one entry translated as %q", entries, "translated: Barev")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// sharedPrompt is the part of a prompt carried by a share code
+type sharedPrompt struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// shareCode encodes the title and text of p as URL-safe base64 of their
+// JSON, to paste into a chat or put in a QR code
+func shareCode(p Prompt) string {
+	data, _ := json.Marshal(sharedPrompt{Title: p.Title, Text: p.Text})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// readShareCode decodes a share code. Whitespace, as left by line
+// wrapping, and padding are ignored.
+func readShareCode(code string) (Prompt, error) {
+	code = strings.TrimRight(strings.Join(strings.Fields(code), ""), "=")
+	data, err := base64.RawURLEncoding.DecodeString(code)
+	if err != nil {
+		return Prompt{}, errors.New("not a LingoSnap share code")
+	}
+	var shared sharedPrompt
+	if err := json.Unmarshal(data, &shared); err != nil {
+		return Prompt{}, errors.New("not a LingoSnap share code")
+	}
+	if strings.TrimSpace(shared.Title) == "" || strings.TrimSpace(shared.Text) == "" {
+		return Prompt{}, errors.New("the shared prompt is missing a title or text")
+	}
+	return Prompt{Title: shared.Title, Text: shared.Text}, nil
+}
+
+// importShareCode adds the prompt of a share code to cfg and saves it. Like
+// -import-prompts, it overwrites a prompt with the same title.
+func importShareCode(cfg *Config, code string) (Prompt, error) {
+	p, err := readShareCode(code)
+	if err != nil {
+		return Prompt{}, err
+	}
+	previous := cfg.Prompts
+	cfg.Prompts = mergePrompts(cfg.Prompts, []Prompt{p}, false)
+	if err := saveConfig(cfg); err != nil {
+		cfg.Prompts = previous
+		return Prompt{}, err
+	}
+	return p, nil
+}
+
+// writeQRCode writes text as a QR code PNG to path with the qrencode
+// command line tool
+func writeQRCode(text, path string) error {
+	qrencode, err := exec.LookPath("qrencode")
+	if err != nil {
+		return errors.New("install qrencode to make QR codes")
+	}
+	if out, err := exec.Command(qrencode, "-o", path, "-s", "6", "--", text).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to make QR code: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
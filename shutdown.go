@@ -0,0 +1,76 @@
+//go:build !headless
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"fyne.io/systray"
+)
+
+// beginTranslation registers a hotkey translation with shutdownWg. It
+// returns false once quitting has started.
+func (t *TranslatorApp) beginTranslation() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.shuttingDown {
+		return false
+	}
+	t.shutdownWg.Add(1)
+	t.inFlight++
+	return true
+}
+
+// endTranslation marks a translation started by beginTranslation done
+func (t *TranslatorApp) endTranslation() {
+	t.mu.Lock()
+	t.inFlight--
+	t.mu.Unlock()
+	t.shutdownWg.Done()
+}
+
+// shutdown stops the hotkeys and waits up to Config.ShutdownTimeoutSecs
+// for the running translations to paste their results
+func (t *TranslatorApp) shutdown() {
+	t.stopHotkeyListener()
+	t.mu.Lock()
+	t.shuttingDown = true
+	running := t.inFlight
+	timeout := time.Duration(t.config.ShutdownTimeoutSecs) * time.Second
+	t.mu.Unlock()
+	if running == 0 || timeout == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("Waiting for %d translations to complete…", running)
+	log.Printf("⏳ %s", msg)
+	go sendNotification("LingoSnap", msg)
+	done := make(chan struct{})
+	go func() {
+		t.shutdownWg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Println("✅ Translations complete")
+	case <-time.After(timeout):
+		slog.Warn(fmt.Sprintf("⚠️  Quitting after %s with translations still running", timeout))
+	}
+}
+
+// shutdownOnSignal quits like the tray's Quit on Ctrl+C or SIGTERM. A
+// second signal exits at once.
+func (t *TranslatorApp) shutdownOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	signal.Stop(signals)
+	t.shutdown()
+	systray.Quit()
+}
@@ -0,0 +1,48 @@
+package main
+
+// levenshtein returns the number of single-rune edits turning a into b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// similarity returns 1 minus the edit distance of a and b relative to the
+// longer one: 1 for identical texts, 0 for entirely different ones
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// nearlyEqual reports whether the similarity of a and b is at least
+// threshold, skipping the edit distance when the lengths alone rule it out
+func nearlyEqual(a, b string, threshold float64) bool {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return true
+	}
+	lengthGap := max(len(ra)-len(rb), len(rb)-len(ra))
+	if 1-float64(lengthGap)/float64(longest) < threshold {
+		return false
+	}
+	return similarity(a, b) >= threshold
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name         string
+		a, b         string
+		wantDistance int
+		want         float64
+	}{
+		{"identical", "hello world", "hello world", 0, 1},
+		{"both empty", "", "", 0, 1},
+		{"one empty", "", "abcd", 4, 0},
+		{"one substitution", "kitten", "sitten", 1, 1 - 1.0/6},
+		{"one insertion", "color", "colour", 1, 1 - 1.0/6},
+		{"one deletion", "colour", "color", 1, 1 - 1.0/6},
+		{"entirely different", "abc", "xyz", 3, 0},
+		{"classic", "kitten", "sitting", 3, 1 - 3.0/7},
+		{"multibyte identical", "Բարև", "Բարև", 0, 1},
+		{"multibyte one edit counts one rune", "Բարև", "Բարէ", 1, 0.75},
+		{"accents are runes", "café", "cafe", 1, 0.75},
+		{"emoji", "hi 👋", "hi 👍", 1, 0.75},
+		{"cjk insertion", "你好", "你好吗", 1, 1 - 1.0/3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.wantDistance {
+				t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.wantDistance)
+			}
+			if got := similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if got := similarity(tt.b, tt.a); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("similarity(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNearlyEqual(t *testing.T) {
+	tests := []struct {
+		a, b      string
+		threshold float64
+		want      bool
+	}{
+		{"", "", 0.9, true},
+		{"same", "same", 1, true},
+		{"kitten", "sitten", 0.8, true},
+		{"kitten", "sitten", 0.9, false},
+		// Ruled out by length alone
+		{"a", "abcdefghij", 0.5, false},
+		{"Բարև ձեզ", "Բարև ձէզ", 0.85, true},
+	}
+	for _, tt := range tests {
+		if got := nearlyEqual(tt.a, tt.b, tt.threshold); got != tt.want {
+			t.Errorf("nearlyEqual(%q, %q, %v) = %v, want %v", tt.a, tt.b, tt.threshold, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SubtitleEntry is one numbered cue of an SRT file
+type SubtitleEntry struct {
+	Index    int
+	TimeCode string
+	Lines    []string
+}
+
+const srtBatchInstruction = `
+
+The text is a list of subtitles, each introduced by a marker line such as [[1]].
+Translate every subtitle and keep each marker line exactly as it is.`
+
+var srtMarkerPattern = regexp.MustCompile(`(?m)^\[\[(\d+)\]\]\s*$`)
+
+// parseSRT reads the cues of an SRT file
+func parseSRT(data string) ([]SubtitleEntry, error) {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+
+	var entries []SubtitleEntry
+	for i, block := range regexp.MustCompile(`\n\s*\n`).Split(strings.TrimSpace(data), -1) {
+		lines := strings.Split(block, "\n")
+		if len(lines) < 2 {
+			return nil, fmt.Errorf("subtitle %d is incomplete", i+1)
+		}
+		index, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+		if err != nil {
+			return nil, fmt.Errorf("subtitle %d has an invalid index %q", i+1, lines[0])
+		}
+		if !strings.Contains(lines[1], "-->") {
+			return nil, fmt.Errorf("subtitle %d has an invalid time code %q", index, lines[1])
+		}
+		entries = append(entries, SubtitleEntry{Index: index, TimeCode: lines[1], Lines: lines[2:]})
+	}
+	return entries, nil
+}
+
+// formatSRT writes entries back in SRT format
+func formatSRT(entries []SubtitleEntry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%d\n%s\n", e.Index, e.TimeCode)
+		for _, line := range e.Lines {
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// translateSRTFile translates the subtitles of path in batches of
+// Config.SRTBatchSize and writes <name>_translated.srt next to it. Cues
+// that fail to translate keep their original text and are reported at the
+// end instead of aborting the file.
+func (t *TranslatorApp) translateSRTFile(path string, prompt Prompt) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	entries, err := parseSRT(decodeText(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	batchSize := max(t.currentConfig().SRTBatchSize, 1)
+	var failed []int
+	for start := 0; start < len(entries); start += batchSize {
+		batch := entries[start:min(start+batchSize, len(entries))]
+		failed = append(failed, t.translateSubtitles(prompt, batch)...)
+		log.Printf("   Subtitles %d/%d", start+len(batch), len(entries))
+	}
+
+	out := translatedPath(path)
+	if err := os.WriteFile(out, []byte(formatSRT(entries)), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	if len(failed) > 0 {
+		slog.Warn(fmt.Sprintf("⚠️  %d subtitles kept their original text: %v", len(failed), failed))
+	}
+	return out, nil
+}
+
+// translateSubtitles translates batch in place with a single request,
+// retrying cues missing from the response one by one. It returns the
+// indexes of cues that could not be translated.
+func (t *TranslatorApp) translateSubtitles(prompt Prompt, batch []SubtitleEntry) []int {
+	var input strings.Builder
+	for _, e := range batch {
+		fmt.Fprintf(&input, "[[%d]]\n%s\n", e.Index, strings.Join(e.Lines, "\n"))
+	}
+
+	translated := make(map[int][]string)
+	if t.takesInstructions() {
+		result, err := t.translateChunk(context.Background(), prompt, input.String(), srtBatchInstruction)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("⚠️  Subtitle batch %d-%d failed: %v", batch[0].Index, batch[len(batch)-1].Index, err))
+		} else {
+			translated = splitSubtitles(result)
+		}
+	}
+
+	var failed []int
+	for i, e := range batch {
+		lines, ok := translated[e.Index]
+		if !ok {
+			single, err := t.translateChunk(context.Background(), prompt, strings.Join(e.Lines, "\n"), "")
+			if err != nil {
+				slog.Warn(fmt.Sprintf("⚠️  Subtitle %d failed: %v", e.Index, err))
+				failed = append(failed, e.Index)
+				continue
+			}
+			lines = strings.Split(single, "\n")
+		}
+		batch[i].Lines = lines
+	}
+	return failed
+}
+
+// splitSubtitles maps the [[n]] markers of a batch response to their lines
+func splitSubtitles(result string) map[int][]string {
+	subtitles := make(map[int][]string)
+	markers := srtMarkerPattern.FindAllStringSubmatchIndex(result, -1)
+	for i, m := range markers {
+		index, _ := strconv.Atoi(result[m[2]:m[3]])
+		end := len(result)
+		if i+1 < len(markers) {
+			end = markers[i+1][0]
+		}
+		if text := strings.TrimSpace(result[m[1]:end]); text != "" {
+			subtitles[index] = strings.Split(text, "\n")
+		}
+	}
+	return subtitles
+}
+
+// translateChunk translates part of a file or document with prompt plus an
+// optional extra instruction, without recording it in the history. Like
+// hotkey translations, it transliterates Armenian and applies
+// Config.PostProcessRules. The instructions are left out for backends
+// that take none.
+func (t *TranslatorApp) translateChunk(ctx context.Context, prompt Prompt, text, instruction string) (string, error) {
+	cfg := t.currentConfig()
+	ctx, cancel := context.WithTimeout(ctx, translationTimeout(cfg, prompt))
+	defer cancel()
+
+	promptText, data, err := t.renderPromptContext(ctx, prompt, text)
+	if err != nil {
+		return "", err
+	}
+	if cfg.TransliterateArmenian {
+		if converted := transliterateArmenian(text, transliterationTable(cfg.TransliterationTable)); converted != text {
+			text = converted
+			instruction += transliterationInstruction
+		}
+	}
+	if !t.takesInstructions() {
+		instruction = ""
+	}
+	ctx, _ = withLanguages(ctx, data.SourceLang, data.TargetLang)
+	translated, err := t.translate(ctx, promptText+instruction, text)
+	if err != nil {
+		return "", err
+	}
+	return t.applyPostProcess(translated), nil
+}
+
+// takesInstructions reports whether the backend follows the prompt. DeepL
+// only takes the languages, so batches that rely on an instruction to keep
+// their markers are sent one item at a time instead.
+func (t *TranslatorApp) takesInstructions() bool {
+	translator, _ := t.backend()
+	_, deepl := translator.(*DeepLTranslator)
+	return !deepl
+}
+
+// translateFile translates a subtitle, text or Markdown file with the
+// selected prompt
+func (t *TranslatorApp) translateFile(path string) error {
+	var out string
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".srt":
+		out, err = t.translateSRTFile(path, t.selectedPrompt())
+	case ".txt", ".md":
+		out, err = t.translateTextFile(path, t.selectedPrompt())
+	default:
+		return fmt.Errorf("only .srt, .txt and .md files can be translated, got %s", path)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ Translation written to %s", out)
+	return nil
+}
+
+// translatedPath returns <name>_translated<ext> for path
+func translatedPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_translated" + ext
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTranslateChunkMatchesHotkeyTranslations(t *testing.T) {
+	fake := &fakeTranslator{}
+	app := newTestApp(t, fake)
+	app.config.TransliterateArmenian = true
+	app.config.PostProcessRules = []PostProcessRule{{Pattern: `translated`, Replacement: "done"}}
+	prompt := Prompt{Title: "Plain", Text: "Translate:"}
+
+	chunk, err := app.translateChunk(context.Background(), prompt, "barev", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hotkey, err := app.translateOnce(context.Background(), prompt, "barev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "done: " + transliterateArmenian("barev", transliterationTable(nil))
+	if chunk != want {
+		t.Errorf("translateChunk() = %q, want %q", chunk, want)
+	}
+	if chunk != hotkey {
+		t.Errorf("translateChunk() = %q, translateOnce() = %q", chunk, hotkey)
+	}
+	if !strings.Contains(fake.prompts[0], transliterationInstruction) {
+		t.Errorf("prompt %q lacks the transliteration note", fake.prompts[0])
+	}
+}
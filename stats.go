@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// statsTopPairs is the number of language pairs shown in statistics
+const statsTopPairs = 10
+
+// statsBarWidth is the length of the longest bar in a chart
+const statsBarWidth = 30
+
+// statsRanges are the date ranges accepted by -stats
+var statsRanges = map[string]time.Duration{
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"all": 0,
+}
+
+// statsSince returns the start of the named date range
+func statsSince(name string, now time.Time) (time.Time, error) {
+	d, ok := statsRanges[name]
+	if !ok {
+		return time.Time{}, fmt.Errorf("unknown statistics range %q, use 7d, 30d or all", name)
+	}
+	if d == 0 {
+		return time.Time{}, nil
+	}
+	return now.Add(-d), nil
+}
+
+// pairLabel names a language pair, with ? for a language that wasn't
+// recorded
+func pairLabel(p LanguagePairCount) string {
+	source, target := p.SourceLang, p.TargetLang
+	if source == "" {
+		source = "?"
+	}
+	if target == "" {
+		target = "?"
+	}
+	return source + " → " + target
+}
+
+// formatPairChart draws pairs as a text bar chart scaled to the most used
+// pair
+func formatPairChart(pairs []LanguagePairCount) string {
+	if len(pairs) == 0 {
+		return "No translations yet"
+	}
+	width := 0
+	for _, p := range pairs {
+		width = max(width, len([]rune(pairLabel(p))))
+	}
+
+	var b strings.Builder
+	for _, p := range pairs {
+		label := pairLabel(p)
+		bar := max(p.Count*statsBarWidth/pairs[0].Count, 1)
+		fmt.Fprintf(&b, "%s%s  %s %d\n", label, strings.Repeat(" ", width-len([]rune(label))),
+			strings.Repeat("█", bar), p.Count)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// latencyBucket is a bar of the latency histogram, holding the latencies
+// below limit. A zero limit holds the rest.
+type latencyBucket struct {
+	label string
+	limit time.Duration
+}
+
+var latencyBuckets = []latencyBucket{
+	{"0–500ms", 500 * time.Millisecond},
+	{"500ms–1s", time.Second},
+	{"1–2s", 2 * time.Second},
+	{"2–5s", 5 * time.Second},
+	{"5s+", 0},
+}
+
+// ModelLatency sums up the latencies of one model in milliseconds
+type ModelLatency struct {
+	Model    string `json:"model"`
+	Count    int    `json:"count"`
+	MedianMs int64  `json:"median_ms"`
+	P95Ms    int64  `json:"p95_ms"`
+	MaxMs    int64  `json:"max_ms"`
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// modelLatencies sums up the sorted latencies of each model, by model name
+func modelLatencies(latencies map[string][]time.Duration) []ModelLatency {
+	var out []ModelLatency
+	for _, model := range slices.Sorted(maps.Keys(latencies)) {
+		l := latencies[model]
+		out = append(out, ModelLatency{
+			Model:    model,
+			Count:    len(l),
+			MedianMs: percentile(l, 50).Milliseconds(),
+			P95Ms:    percentile(l, 95).Milliseconds(),
+			MaxMs:    l[len(l)-1].Milliseconds(),
+		})
+	}
+	return out
+}
+
+// formatLatencyChart draws the latencies of every model as a text
+// histogram, followed by the median, 95th percentile and maximum of each
+func formatLatencyChart(latencies map[string][]time.Duration) string {
+	counts := make([]int, len(latencyBuckets))
+	for _, l := range latencies {
+		for _, d := range l {
+			i := slices.IndexFunc(latencyBuckets, func(b latencyBucket) bool {
+				return b.limit == 0 || d < b.limit
+			})
+			counts[i]++
+		}
+	}
+	most := slices.Max(counts)
+	if most == 0 {
+		return "No translations yet"
+	}
+
+	width := 0
+	for _, bucket := range latencyBuckets {
+		width = max(width, len([]rune(bucket.label)))
+	}
+	var b strings.Builder
+	for i, bucket := range latencyBuckets {
+		bar := strings.Repeat("█", counts[i]*statsBarWidth/most)
+		if counts[i] > 0 && bar == "" {
+			bar = "▏"
+		}
+		padding := strings.Repeat(" ", width-len([]rune(bucket.label)))
+		fmt.Fprintf(&b, "%s%s  %s %d\n", bucket.label, padding, bar, counts[i])
+	}
+	for _, m := range modelLatencies(latencies) {
+		fmt.Fprintf(&b, "\n%s: median %dms, p95 %dms, max %dms (%d translations)",
+			m.Model, m.MedianMs, m.P95Ms, m.MaxMs, m.Count)
+	}
+	return b.String()
+}
+
+// languageStats is the file written by -export-stats
+type languageStats struct {
+	Range   string              `json:"range"`
+	Since   *time.Time          `json:"since,omitempty"` // nil for all time
+	Pairs   []LanguagePairCount `json:"pairs"`
+	Latency []ModelLatency      `json:"latency"`
+}
+
+// exportStats writes the top language pairs and the latencies since the
+// start of the range to path as JSON
+func exportStats(path, rangeName string, since time.Time, pairs []LanguagePairCount, latencies map[string][]time.Duration) error {
+	stats := languageStats{Range: rangeName, Pairs: pairs, Latency: modelLatencies(latencies)}
+	if !since.IsZero() {
+		stats.Since = &since
+	}
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode statistics: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// structuredBatchSize is the number of string values sent per request
+const structuredBatchSize = 20
+
+const structuredBatchInstruction = `
+
+The text is a list of values, each introduced by a marker line such as [[1]].
+Translate every value independently and keep each marker line exactly as it is.`
+
+// translateStructured translates the string values of a JSON or YAML
+// document, leaving keys, numbers and the layout alone. ok is false when
+// text is neither, so it can be translated as plain text instead.
+func (t *TranslatorApp) translateStructured(ctx context.Context, prompt Prompt, text string) (_ string, ok bool, err error) {
+	trimmed := strings.TrimSpace(text)
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		result, err := t.translateJSON(ctx, prompt, text)
+		return result, true, err
+	}
+
+	// A single line such as "Note: call me" is valid YAML too, so only
+	// multi-line documents are treated as YAML
+	if !strings.Contains(trimmed, "\n") {
+		return "", false, nil
+	}
+	var doc yaml.Node
+	if yaml.Unmarshal([]byte(text), &doc) != nil || len(doc.Content) == 0 {
+		return "", false, nil
+	}
+	if kind := doc.Content[0].Kind; kind != yaml.MappingNode && kind != yaml.SequenceNode {
+		return "", false, nil
+	}
+	result, err := t.translateYAML(ctx, prompt, text, &doc)
+	return result, true, err
+}
+
+// translateJSON rewrites the JSON document text with its string values
+// translated, keeping the key order and indentation
+func (t *TranslatorApp) translateJSON(ctx context.Context, prompt Prompt, text string) (string, error) {
+	var values []string
+	if _, err := rewriteJSON(text, func(s string) string {
+		values = append(values, s)
+		return s
+	}); err != nil {
+		return "", err
+	}
+
+	translated, err := t.translateValues(ctx, prompt, values)
+	if err != nil {
+		return "", err
+	}
+	compact, err := rewriteJSON(text, func(s string) string {
+		if tr, ok := translated[s]; ok {
+			return tr
+		}
+		return s
+	})
+	if err != nil {
+		return "", err
+	}
+
+	indent := jsonIndent(text)
+	if indent == "" {
+		return compact + trailingNewline(text), nil
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(compact), "", indent); err != nil {
+		return "", fmt.Errorf("failed to format JSON: %w", err)
+	}
+	return out.String() + trailingNewline(text), nil
+}
+
+// rewriteJSON re-encodes the JSON document text compactly, passing every
+// string value, but not object keys, through fn
+func rewriteJSON(text string, fn func(string) string) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+
+	var out bytes.Buffer
+	if err := rewriteJSONValue(dec, &out, fn); err != nil {
+		return "", fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return out.String(), nil
+}
+
+// rewriteJSONValue copies the next value from dec to out, recursing into
+// objects and arrays
+func rewriteJSONValue(dec *json.Decoder, out *bytes.Buffer, fn func(string) string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		out.WriteRune(rune(v))
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if v == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				writeJSONString(out, key.(string))
+				out.WriteByte(':')
+			}
+			if err := rewriteJSONValue(dec, out, fn); err != nil {
+				return err
+			}
+		}
+		closing, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		out.WriteRune(rune(closing.(json.Delim)))
+	case string:
+		writeJSONString(out, fn(v))
+	case json.Number:
+		out.WriteString(v.String())
+	case bool:
+		fmt.Fprint(out, v)
+	case nil:
+		out.WriteString("null")
+	}
+	return nil
+}
+
+// writeJSONString writes s as a JSON string without escaping <, > and &
+func writeJSONString(out *bytes.Buffer, s string) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	out.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+// jsonIndent returns the indentation of the first indented line of text,
+// or "" when the document is on one line
+func jsonIndent(text string) string {
+	for _, line := range strings.Split(text, "\n")[1:] {
+		if trimmed := strings.TrimLeft(line, " \t"); trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return ""
+}
+
+// trailingNewline returns "\n" when text ends with one
+func trailingNewline(text string) string {
+	if strings.HasSuffix(text, "\n") {
+		return "\n"
+	}
+	return ""
+}
+
+// translateYAML translates the string values of doc, the parsed form of
+// text. Comments and styles are kept by the node tree.
+func (t *TranslatorApp) translateYAML(ctx context.Context, prompt Prompt, text string, doc *yaml.Node) (string, error) {
+	var leaves []*yaml.Node
+	collectYAMLStrings(doc, &leaves)
+
+	values := make([]string, len(leaves))
+	for i, n := range leaves {
+		values[i] = n.Value
+	}
+	translated, err := t.translateValues(ctx, prompt, values)
+	if err != nil {
+		return "", err
+	}
+	for _, n := range leaves {
+		if tr, ok := translated[n.Value]; ok {
+			n.Value = tr
+		}
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(max(len(yamlIndent(text)), 2))
+	if err := enc.Encode(doc); err != nil {
+		return "", fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return out.String(), nil
+}
+
+// collectYAMLStrings appends the string scalars under n, skipping mapping
+// keys
+func collectYAMLStrings(n *yaml.Node, leaves *[]*yaml.Node) {
+	switch n.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, c := range n.Content {
+			collectYAMLStrings(c, leaves)
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			collectYAMLStrings(n.Content[i], leaves)
+		}
+	case yaml.ScalarNode:
+		if n.ShortTag() == "!!str" && strings.TrimSpace(n.Value) != "" {
+			*leaves = append(*leaves, n)
+		}
+	}
+}
+
+// yamlIndent returns the indentation of the first indented line of text
+// that isn't a comment
+func yamlIndent(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed != "" && len(trimmed) < len(line) && !strings.HasPrefix(trimmed, "#") {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return ""
+}
+
+// translateValues translates each distinct value on its own, in batches of
+// structuredBatchSize, and maps the originals to their translations.
+// Values missing from a batch response are retried one by one.
+func (t *TranslatorApp) translateValues(ctx context.Context, prompt Prompt, values []string) (map[string]string, error) {
+	var unique []string
+	translated := make(map[string]string)
+	for _, v := range values {
+		if _, ok := translated[v]; !ok && strings.TrimSpace(v) != "" {
+			translated[v] = v
+			unique = append(unique, v)
+		}
+	}
+
+	for start := 0; start < len(unique); start += structuredBatchSize {
+		batch := unique[start:min(start+structuredBatchSize, len(unique))]
+
+		lines := make(map[int][]string)
+		if t.takesInstructions() {
+			var input strings.Builder
+			for i, v := range batch {
+				fmt.Fprintf(&input, "[[%d]]\n%s\n", i+1, v)
+			}
+			result, err := t.translateChunk(ctx, prompt, input.String(), structuredBatchInstruction)
+			if err != nil {
+				return nil, err
+			}
+			lines = splitSubtitles(result)
+		}
+
+		for i, v := range batch {
+			if tr, ok := lines[i+1]; ok {
+				translated[v] = strings.Join(tr, "\n")
+				continue
+			}
+			single, err := t.translateChunk(ctx, prompt, v, "")
+			if err != nil {
+				return nil, err
+			}
+			translated[v] = single
+		}
+		log.Printf("   Values %d/%d", start+len(batch), len(unique))
+	}
+	return translated, nil
+}
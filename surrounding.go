@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+const surroundingInstruction = "\n\nThe text is shown with the sentences around it for context. Translate only the part between <translate> and </translate> and return only its translation, without the markers; use the rest to disambiguate."
+
+// textEditors are lowercase pieces of the window titles of apps whose
+// text around the selection is read for Config.ContextLines
+var textEditors = []string{
+	"visual studio code", "sublime text", "notepad", "textedit", "gedit",
+	"kate", "microsoft word", "libreoffice", "pages", "obsidian", "notion",
+	"google docs", "vim", "emacs", "zed",
+}
+
+// sentence matches a sentence with the spaces after it; a line break ends
+// a sentence too
+var sentence = regexp.MustCompile(`[^.!?…。\n]*(?:[.!?…。]+|\n|$)[ \t]*\n?`)
+
+// surroundingKey carries the text around the one being translated
+type surroundingKey struct{}
+
+type surroundingText struct {
+	before, after string
+}
+
+// withSurroundingText asks for the translation under ctx to take before
+// and after, the text around it, into account
+func withSurroundingText(ctx context.Context, before, after string) context.Context {
+	if strings.TrimSpace(before) == "" && strings.TrimSpace(after) == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, surroundingKey{}, surroundingText{before, after})
+}
+
+// surroundingFrom returns the text set by withSurroundingText
+func surroundingFrom(ctx context.Context) (surroundingText, bool) {
+	s, ok := ctx.Value(surroundingKey{}).(surroundingText)
+	return s, ok
+}
+
+// isTextEditor reports whether the window title belongs to a known text
+// editor
+func isTextEditor(title string) bool {
+	title = strings.ToLower(title)
+	for _, editor := range textEditors {
+		if strings.Contains(title, editor) {
+			return true
+		}
+	}
+	return false
+}
+
+// sentencesAround keeps the last n sentences of before and the first n of
+// after
+func sentencesAround(before, after string, n int) (string, string) {
+	spans := sentence.FindAllStringIndex(before, -1)
+	var kept []int
+	for _, s := range spans {
+		if s[1] > s[0] {
+			kept = append(kept, s[0])
+		}
+	}
+	if len(kept) > n {
+		before = before[kept[len(kept)-n]:]
+	}
+
+	count := 0
+	for _, s := range sentence.FindAllStringIndex(after, -1) {
+		if s[1] == s[0] {
+			continue
+		}
+		if count++; count == n {
+			after = after[:s[1]]
+			break
+		}
+	}
+	return before, after
+}
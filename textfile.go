@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const continuationInstruction = `
+
+The text continues a document. The previous part ended with this sentence, which is given only for context and must not be part of your answer:
+%s`
+
+var (
+	paragraphSeparator = regexp.MustCompile(`\n\s*\n`)
+	sentenceEnd        = regexp.MustCompile(`[.!?…]+["')\]]*\s+`)
+)
+
+// decodeText returns data as a string, reading it as Windows-1252 when
+// it isn't valid UTF-8
+func decodeText(data []byte) string {
+	if utf8.Valid(data) {
+		return strings.TrimPrefix(string(data), "\ufeff")
+	}
+	return decodeLegacy(string(data))
+}
+
+// splitSentences splits text after sentence-ending punctuation
+func splitSentences(text string) []string {
+	var sentences []string
+	last := 0
+	for _, m := range sentenceEnd.FindAllStringIndex(text, -1) {
+		sentences = append(sentences, text[last:m[1]])
+		last = m[1]
+	}
+	if last < len(text) {
+		sentences = append(sentences, text[last:])
+	}
+	return sentences
+}
+
+// textChunk is a part of a file sent in one request. Separator joins it to
+// the previous chunk: a blank line between paragraphs, or a space when a
+// long paragraph was split.
+type textChunk struct {
+	Text      string
+	Separator string
+}
+
+// chunkText groups the paragraphs of text into chunks of at most maxChars,
+// splitting paragraphs that are too long on sentence boundaries
+func chunkText(text string, maxChars int) []textChunk {
+	var chunks []textChunk
+	var current strings.Builder
+	separator := ""
+	flush := func(next string) {
+		if current.Len() > 0 {
+			chunks = append(chunks, textChunk{Text: strings.TrimSpace(current.String()), Separator: separator})
+			current.Reset()
+			separator = next
+		}
+	}
+
+	for _, paragraph := range paragraphSeparator.Split(strings.TrimSpace(text), -1) {
+		if len(paragraph) > maxChars {
+			flush("\n\n")
+			for _, sentence := range splitSentences(paragraph) {
+				if current.Len() > 0 && current.Len()+len(sentence) > maxChars {
+					flush(" ")
+				}
+				current.WriteString(sentence)
+			}
+			flush("\n\n")
+			continue
+		}
+		if current.Len() > 0 && current.Len()+2+len(paragraph) > maxChars {
+			flush("\n\n")
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(paragraph)
+	}
+	flush("")
+	return chunks
+}
+
+// lastSentence returns the final sentence of text
+func lastSentence(text string) string {
+	sentences := splitSentences(strings.TrimSpace(text))
+	if len(sentences) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(sentences[len(sentences)-1])
+}
+
+// translateTextFile translates a plain text or Markdown file chunk by
+// chunk and writes <name>_translated.<ext> next to it. Each chunk is sent
+// with the last sentence of the previous one so the model keeps the thread.
+func (t *TranslatorApp) translateTextFile(path string, prompt Prompt) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	chunks := chunkText(decodeText(data), max(t.currentConfig().MaxChunkChars, 1))
+	var translated strings.Builder
+	start := time.Now()
+	for i, chunk := range chunks {
+		instruction := ""
+		if i > 0 {
+			instruction = fmt.Sprintf(continuationInstruction, lastSentence(chunks[i-1].Text))
+		}
+		result, err := t.translateChunk(context.Background(), prompt, chunk.Text, instruction)
+		if err != nil {
+			return "", fmt.Errorf("failed to translate part %d of %d: %w", i+1, len(chunks), err)
+		}
+		translated.WriteString(chunk.Separator + result)
+		log.Printf("   Part %d/%d (%s elapsed)", i+1, len(chunks), time.Since(start).Round(time.Second))
+	}
+
+	out := translatedPath(path)
+	if err := os.WriteFile(out, []byte(translated.String()+"\n"), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	return out, nil
+}
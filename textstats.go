@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// countWords counts space-separated words. Chinese and Japanese don't put
+// spaces between words, so each of their characters counts as one.
+func countWords(text string) int {
+	words := 0
+	for _, field := range strings.Fields(text) {
+		inWord := false
+		for _, r := range field {
+			if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+				words++
+				inWord = false
+			} else if !inWord {
+				words++
+				inWord = true
+			}
+		}
+	}
+	return words
+}
+
+// textStats summarises the size of a translation for the log
+func textStats(in, out string, latency time.Duration) string {
+	return fmt.Sprintf("Last: %d chars / %d words in → %d chars / %d words out (%dms)",
+		utf8.RuneCountInString(in), countWords(in),
+		utf8.RuneCountInString(out), countWords(out), latency.Milliseconds())
+}
+
+// zeroWidthJoiner joins emoji into one, e.g. a family from its members
+const zeroWidthJoiner = '\u200d'
+
+// graphemeCount counts the characters a cursor moves over in text, which
+// is fewer than its runes: a character and the combining marks, variation
+// selectors, emoji modifiers and tags after it count once, as do emoji
+// joined by a zero width joiner, the two halves of a flag and CR LF. It
+// follows the Unicode grapheme cluster rules closely enough for pasted
+// text, without their complete tables.
+func graphemeCount(text string) int {
+	count := 0
+	prev := rune(-1)
+	regional := 0 // regional indicators in a row, which pair into flags
+	for _, r := range text {
+		switch {
+		case prev == '\r' && r == '\n':
+		case prev == zeroWidthJoiner:
+		case prev != -1 && extendsGrapheme(r):
+		case isRegionalIndicator(r) && regional%2 == 1:
+		default:
+			count++
+		}
+		if isRegionalIndicator(r) {
+			regional++
+		} else {
+			regional = 0
+		}
+		prev = r
+	}
+	return count
+}
+
+// extendsGrapheme reports whether r belongs to the character before it
+func extendsGrapheme(r rune) bool {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true // combining marks, including variation selectors
+	case r == zeroWidthJoiner || r == '\u200c': // and non-joiner
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff:
+		return true // emoji skin tones
+	case r >= 0xe0020 && r <= 0xe007f:
+		return true // tags of subdivision flags
+	case r >= 0x1160 && r <= 0x11ff, r >= 0xd7b0 && r <= 0xd7ff:
+		return true // vowels and final consonants of decomposed Hangul
+	}
+	return false
+}
+
+// isRegionalIndicator reports whether r is one of the letters flags are
+// spelled with
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestGraphemeCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"Բարեւ", 5},
+		{"a\r\nb", 3},
+		{"e\u0301", 1},              // e with a combining acute accent
+		{"\u0301", 1},               // a combining mark alone
+		{"\U0001f44d\U0001f3fd", 1}, // skin tone
+		{"\U0001f468\u200d\U0001f469\u200d\U0001f467", 1}, // family joined by ZWJ
+		{"\u2764\ufe0f", 1},                                                           // variation selector
+		{"\U0001f1e6\U0001f1f2\U0001f1e9\U0001f1ea", 2},                               // two flags
+		{"\U0001f1e6\U0001f1f2\U0001f1e9", 2},                                         // a flag and half of another
+		{"\U0001f3f4\U000e0067\U000e0062\U000e0073\U000e0063\U000e0074\U000e007f", 1}, // Scotland
+		{"\ud558\u1161", 1},                                                           // decomposed Hangul vowel
+		{"नमस्ते", 4},                                                                 // न म स् ते
+	}
+	for _, tt := range tests {
+		if got := graphemeCount(tt.text); got != tt.want {
+			t.Errorf("graphemeCount(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
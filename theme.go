@@ -0,0 +1,51 @@
+package main
+
+import "fmt"
+
+// Values for Config.Theme
+const (
+	themeSystem = "system"
+	themeDark   = "dark"
+	themeLight  = "light"
+)
+
+// Range of Config.UIScale
+const (
+	minUIScale = 0.5
+	maxUIScale = 3.0
+)
+
+// Size in pixels of the text editing window at scale 1
+const (
+	editorWidth  = 500
+	editorHeight = 400
+)
+
+// dialogTheme is the Config.Theme dialogs are shown with, set when the
+// desktop app starts
+var dialogTheme = themeSystem
+
+// dialogHighContrast is Config.KeyboardNav, set when the desktop app starts
+var dialogHighContrast bool
+
+// dialogScale is Config.UIScale clamped to the screen, set when the
+// desktop app starts
+var dialogScale float32 = 1
+
+// dialogWidth and dialogHeight are Config.DialogWidth and DialogHeight
+// clamped to the screen, set when the desktop app starts. 0 lets the
+// dialog size itself.
+var dialogWidth, dialogHeight int
+
+// checkTheme rejects unknown themes and scales out of range
+func checkTheme(cfg *Config) error {
+	switch cfg.Theme {
+	case "", themeSystem, themeDark, themeLight:
+	default:
+		return fmt.Errorf("theme must be %q, %q or %q, got %q", themeSystem, themeDark, themeLight, cfg.Theme)
+	}
+	if cfg.UIScale < minUIScale || cfg.UIScale > maxUIScale {
+		return fmt.Errorf("ui_scale must be between %g and %g, got %g", minUIScale, maxUIScale, cfg.UIScale)
+	}
+	return nil
+}
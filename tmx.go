@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// tmxUnit is a translation unit of a TMX file. Tags carry no namespace so
+// both TMX 1.4 and the namespaced 2.0 drafts decode, and lang matches
+// xml:lang as well as the lang attribute of older versions.
+type tmxUnit struct {
+	Props []struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"prop"`
+	Variants []struct {
+		Lang string `xml:"lang,attr"`
+		Seg  string `xml:"seg"`
+	} `xml:"tuv"`
+}
+
+// domain returns the unit's x-domain property
+func (u tmxUnit) domain() string {
+	for _, p := range u.Props {
+		if p.Type == "x-domain" {
+			return strings.TrimSpace(p.Value)
+		}
+	}
+	return ""
+}
+
+// segment returns the text of the unit in lang
+func (u tmxUnit) segment(lang string) string {
+	for _, v := range u.Variants {
+		if languageMatches(v.Lang, lang) {
+			return strings.Join(strings.Fields(v.Seg), " ")
+		}
+	}
+	return ""
+}
+
+// languageMatches reports whether the language tag of a segment, such as
+// "en-US", is lang. A tag without region matches every region.
+func languageMatches(tag, lang string) bool {
+	if strings.EqualFold(tag, lang) {
+		return true
+	}
+	tagPrimary, tagRegion, _ := strings.Cut(tag, "-")
+	langPrimary, langRegion, _ := strings.Cut(lang, "-")
+	return (tagRegion == "" || langRegion == "") && strings.EqualFold(tagPrimary, langPrimary)
+}
+
+// readTMX returns the glossary entries of the translation units in r from
+// source to target. source defaults to the srclang of the header. With
+// domain set, only units whose x-domain property equals it are read.
+func readTMX(r io.Reader, source, target, domain string) ([]GlossaryEntry, error) {
+	if target == "" {
+		return nil, errors.New("the target language is required")
+	}
+	dec := xml.NewDecoder(r)
+	var entries []GlossaryEntry
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, tmxError(dec, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "header":
+			for _, a := range start.Attr {
+				if a.Name.Local == "srclang" && source == "" && a.Value != "*all*" {
+					source = a.Value
+				}
+			}
+		case "tu":
+			if source == "" {
+				return nil, errors.New("the file doesn't name its source language, pass it explicitly")
+			}
+			line, _ := dec.InputPos()
+			var u tmxUnit
+			if err := dec.DecodeElement(&u, &start); err != nil {
+				var syntax *xml.SyntaxError
+				if errors.As(err, &syntax) {
+					return nil, tmxError(dec, err)
+				}
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if domain != "" && !strings.EqualFold(u.domain(), domain) {
+				continue
+			}
+			if s, t := u.segment(source), u.segment(target); s != "" && t != "" {
+				entries = append(entries, GlossaryEntry{Source: s, Target: t})
+			}
+		}
+	}
+	return entries, nil
+}
+
+// tmxError adds the line of a parsing error to it
+func tmxError(dec *xml.Decoder, err error) error {
+	var syntax *xml.SyntaxError
+	if errors.As(err, &syntax) {
+		return fmt.Errorf("line %d: %s", syntax.Line, syntax.Msg)
+	}
+	line, _ := dec.InputPos()
+	return fmt.Errorf("line %d: %w", line, err)
+}
+
+// importTMX adds the terms of the TMX file at path to the glossary in cfg
+// and saves it, skipping terms the glossary already has. It returns how
+// many terms were added.
+func importTMX(cfg *Config, path, source, target, domain string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open TMX file: %w", err)
+	}
+	defer f.Close()
+	entries, err := readTMX(f, source, target, domain)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	previous := cfg.Glossary
+	cfg.Glossary = mergeGlossary(cfg.Glossary, entries)
+	if err := saveConfig(cfg); err != nil {
+		cfg.Glossary = previous
+		return 0, err
+	}
+	return len(cfg.Glossary) - len(previous), nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<tmx version="1.4">
+  <header srclang="en-US" datatype="plaintext" segtype="phrase"/>
+  <body>
+    <tu>
+      <prop type="x-domain">legal</prop>
+      <tuv xml:lang="en-US"><seg>terms of   service</seg></tuv>
+      <tuv xml:lang="de-DE"><seg>Nutzungsbedingungen</seg></tuv>
+    </tu>
+    <tu>
+      <tuv xml:lang="en"><seg>invoice</seg></tuv>
+      <tuv xml:lang="de"><seg>Rechnung</seg></tuv>
+      <tuv lang="fr"><seg>facture</seg></tuv>
+    </tu>
+    <tu>
+      <tuv xml:lang="en-US"><seg>untranslated</seg></tuv>
+    </tu>
+    <tu>
+      <tuv xml:lang="en-GB"><seg>colour</seg></tuv>
+      <tuv xml:lang="de-AT"><seg>Farbe</seg></tuv>
+    </tu>
+  </body>
+</tmx>`
+
+func TestReadTMX(t *testing.T) {
+	tests := []struct {
+		name                   string
+		source, target, domain string
+		want                   []GlossaryEntry
+	}{
+		{
+			name:   "source from the header",
+			target: "de-DE",
+			want: []GlossaryEntry{
+				{Source: "terms of service", Target: "Nutzungsbedingungen"},
+				{Source: "invoice", Target: "Rechnung"},
+			},
+		},
+		{
+			name:   "language without region matches every region",
+			source: "en",
+			target: "de",
+			want: []GlossaryEntry{
+				{Source: "terms of service", Target: "Nutzungsbedingungen"},
+				{Source: "invoice", Target: "Rechnung"},
+				{Source: "colour", Target: "Farbe"},
+			},
+		},
+		{
+			name:   "other region doesn't match",
+			source: "en-GB",
+			target: "de-AT",
+			want: []GlossaryEntry{
+				{Source: "invoice", Target: "Rechnung"},
+				{Source: "colour", Target: "Farbe"},
+			},
+		},
+		{
+			name:   "lang attribute of older versions",
+			source: "en",
+			target: "FR",
+			want:   []GlossaryEntry{{Source: "invoice", Target: "facture"}},
+		},
+		{
+			name:   "domain",
+			source: "en",
+			target: "de",
+			domain: "Legal",
+			want:   []GlossaryEntry{{Source: "terms of service", Target: "Nutzungsbedingungen"}},
+		},
+		{
+			name:   "no segments in the target language",
+			source: "en",
+			target: "hy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := readTMX(strings.NewReader(testTMX), tt.source, tt.target, tt.domain)
+			if err != nil {
+				t.Fatalf("readTMX() failed: %v", err)
+			}
+			if !slices.Equal(entries, tt.want) {
+				t.Errorf("readTMX() = %v, want %v", entries, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadTMXErrors(t *testing.T) {
+	tests := []struct {
+		name, tmx, target, wantErr string
+	}{
+		{"no target", testTMX, "", "target language is required"},
+		{"no source language", `<tmx><header srclang="*all*"/><body><tu></tu></body></tmx>`, "de", "source language"},
+		{"broken XML", "<tmx>\n<header srclang=\"en\"/>\n<body><tu><tuv></tu></body></tmx>", "de", "line 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readTMX(strings.NewReader(tt.tmx), "", tt.target, "")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("readTMX() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !otel
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// traceSpan is a no-op in builds without the otel tag
+type traceSpan struct{}
+
+// initTracing is a no-op; build with -tags otel to export traces
+func initTracing(cfg *Config) (func(context.Context) error, error) {
+	if cfg.OTLPEndpoint != "" {
+		slog.Warn("⚠️  otlp_endpoint is set but this build has no tracing support, rebuild with -tags otel")
+	}
+	return func(context.Context) error { return nil }, nil
+}
+
+func startSpan(ctx context.Context, name, model, promptTitle string) (context.Context, traceSpan) {
+	return ctx, traceSpan{}
+}
+
+func (traceSpan) setTokens(input, output int64) {}
+
+func (traceSpan) end(err error) {}
+
+// tracedTransport returns rt unchanged
+func tracedTransport(rt http.RoundTripper) http.RoundTripper {
+	return rt
+}
@@ -0,0 +1,76 @@
+//go:build otel
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/Vardan1995/lingosnap"
+
+// traceSpan wraps an OpenTelemetry span
+type traceSpan struct {
+	span trace.Span
+}
+
+// initTracing exports spans over OTLP/HTTP to Config.OTLPEndpoint. The
+// returned function flushes pending spans and must be called on exit.
+func initTracing(cfg *Config) (func(context.Context) error, error) {
+	if cfg.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName("lingosnap"))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// startSpan starts a child span of the span in ctx, if any
+func startSpan(ctx context.Context, name, model, promptTitle string) (context.Context, traceSpan) {
+	attrs := []attribute.KeyValue{attribute.String("lingosnap.model", model)}
+	if promptTitle != "" {
+		attrs = append(attrs, attribute.String("lingosnap.prompt_title", promptTitle))
+	}
+	ctx, span := otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, traceSpan{span: span}
+}
+
+func (s traceSpan) setTokens(input, output int64) {
+	s.span.SetAttributes(
+		attribute.Int64("lingosnap.input_tokens", input),
+		attribute.Int64("lingosnap.output_tokens", output),
+	)
+}
+
+// end records err, if any, and ends the span
+func (s traceSpan) end(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+// tracedTransport wraps rt to record a span per HTTP round trip
+func tracedTransport(rt http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(rt)
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+const (
+	providerGemini = "gemini"
+	providerOpenAI = "openai"
+	providerDeepL  = "deepl"
+)
+
+// providerModels lists the models offered for each provider; the first
+// entry is used when the configured model belongs to another provider
+var providerModels = map[string][]string{
+	providerGemini: {"gemini-2.0-flash", "gemini-2.0-flash-lite", "gemini-1.5-flash", "gemini-1.5-pro"},
+	providerOpenAI: {"gpt-4o-mini", "gpt-4o", "gpt-4.1-mini"},
+	// DeepL has no models to pick, only the model_type of a request
+	providerDeepL: {"latency_optimized", "quality_optimized", "prefer_quality_optimized"},
+}
+
+// Translator sends a prompt and the text to translate to a model backend
+type Translator interface {
+	Translate(ctx context.Context, prompt, text string) (string, error)
+}
+
+// newTranslator picks the backend configured in Config.Provider. Token
+// usage reported by the backend is added to usage.
+func newTranslator(cfg *Config, usage *usageTracker) (Translator, error) {
+	models, ok := providerModels[cfg.Provider]
+	if !ok && cfg.PluginDir != "" {
+		// Any other provider may be a plugin; the plugin picks its model
+		if p, ok := loadPlugins(cfg.PluginDir)[cfg.Provider]; ok {
+			return &pluginTranslator{plugin: p, timeout: requestTimeout(cfg, Prompt{})}, nil
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", cfg.Provider)
+	}
+	// Self-hosted and fine-tuned models aren't in the list
+	customModel := cfg.Provider == providerGemini && cfg.CustomEndpoint != ""
+	if customModel && cfg.Model == "" {
+		return nil, fmt.Errorf("model is required with custom_endpoint")
+	}
+	if !customModel && (cfg.Model == "" || isOtherProviderModel(cfg.Provider, cfg.Model)) {
+		slog.Warn(fmt.Sprintf("⚠️  Model %q is not a %s model, using %s", cfg.Model, cfg.Provider, models[0]))
+		cfg.Model = models[0]
+	}
+
+	apiKey, err := resolveAPIKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Provider {
+	case providerGemini:
+		// A single key is treated as a pool of one
+		keys, err := resolveAPIKeys(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) == 0 && apiKey != "" {
+			keys = []string{apiKey}
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("GEMINI_API_KEY environment variable or api_key setting is required")
+		}
+		return &GeminiTranslator{
+			config:     func() *Config { return cfg },
+			keys:       newKeyPool(keys, cfg.ThrottleCooldown.Std()),
+			httpClient: httpClient,
+			usage:      usage,
+		}, nil
+	case providerOpenAI:
+		return newOpenAITranslator(cfg.BaseURL, cfg.Model, apiKey, httpClient, usage), nil
+	case providerDeepL:
+		if apiKey == "" {
+			return nil, fmt.Errorf("DEEPL_API_KEY environment variable or deepl_key setting is required")
+		}
+		return newDeepLTranslator(cfg.BaseURL, cfg.Model, apiKey, httpClient), nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q", cfg.Provider)
+	}
+}
+
+// clientSettings returns the settings newTranslator builds a backend
+// from. A backend has to be rebuilt when they change; other settings are
+// read on each request.
+func clientSettings(cfg *Config) string {
+	data, _ := json.Marshal([]any{
+		cfg.Provider, cfg.Model, cfg.BaseURL, cfg.CustomEndpoint, cfg.APIKey, cfg.APIKeys, cfg.DeepLKey,
+		cfg.PluginDir, cfg.ThrottleCooldown, cfg.HTTPProxy, cfg.NoProxy, cfg.TimeoutSecs,
+		cfg.MaxRequestsPerMin, cfg.MaxTokensPerMin,
+	})
+	return string(data)
+}
+
+// isOtherProviderModel reports whether model is one of the models offered
+// for a provider other than the given one
+func isOtherProviderModel(provider, model string) bool {
+	for p, models := range providerModels {
+		if p != provider && slices.Contains(models, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// GeminiTranslator translates through the Google Gemini API
+type GeminiTranslator struct {
+	config     func() *Config // the config in use, read on each request
+	keys       *keyPool
+	httpClient *http.Client
+	usage      *usageTracker
+
+	mu      sync.Mutex
+	clients map[int]*genai.Client // by key index, created on first use
+}
+
+func (g *GeminiTranslator) Translate(ctx context.Context, prompt, text string) (string, error) {
+	result, err := g.generate(ctx, prompt, text, generateConfig(g.config()))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(result.Text()), nil
+}
+
+// TranslateCandidates asks Gemini for n candidate translations
+func (g *GeminiTranslator) TranslateCandidates(ctx context.Context, prompt, text string, n int) ([]string, error) {
+	config := generateConfig(g.config())
+	config.CandidateCount = int32(n)
+	result, err := g.generate(ctx, prompt, text, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []string
+	for _, c := range result.Candidates {
+		if c.Content == nil {
+			continue
+		}
+		var text strings.Builder
+		for _, part := range c.Content.Parts {
+			if !part.Thought {
+				text.WriteString(part.Text)
+			}
+		}
+		if s := strings.TrimSpace(text.String()); s != "" {
+			candidates = append(candidates, s)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("generation failed: empty response")
+	}
+	return candidates, nil
+}
+
+func (g *GeminiTranslator) generate(ctx context.Context, prompt, text string, config *genai.GenerateContentConfig) (_ *genai.GenerateContentResponse, err error) {
+	cfg := g.config()
+	ctx, span := startSpan(ctx, "gemini.generate_content", cfg.Model, "")
+	defer func() { span.end(err) }()
+
+	var result *genai.GenerateContentResponse
+	err = withRetry(ctx, cfg.MaxRetries, cfg.RetryBackoffBase.Std(), func() error {
+		// Each attempt takes the next key so a 429 moves on to another one
+		index, client, err := g.client(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		result, err = client.Models.GenerateContent(
+			ctx,
+			cfg.Model,
+			genai.Text(prompt+"\n\n"+text),
+			config,
+		)
+		if isRateLimited(err) {
+			g.keys.throttle(index)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generation failed: %w", err)
+	}
+	recordGeminiUsage(g.usage, span, cfg.Model, result.UsageMetadata)
+	if len(result.Candidates) > 0 {
+		setFinishReason(ctx, result.Candidates[0].FinishReason)
+	}
+	return result, nil
+}
+
+// client returns the client for the next key of the pool. Clients are
+// created on first use and kept, so requests reuse their connections.
+func (g *GeminiTranslator) client(ctx context.Context, cfg *Config) (int, *genai.Client, error) {
+	index, key := g.keys.next()
+	if len(g.keys.keys) > 1 {
+		log.Printf("   Using API key #%d", index+1)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if client, ok := g.clients[index]; ok {
+		return index, client, nil
+	}
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:      key,
+		HTTPClient:  g.httpClient,
+		HTTPOptions: genai.HTTPOptions{BaseURL: cfg.CustomEndpoint},
+	})
+	if err != nil {
+		return index, nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	if g.clients == nil {
+		g.clients = make(map[int]*genai.Client)
+	}
+	g.clients[index] = client
+	return index, client, nil
+}
+
+// recordGeminiUsage adds the token counts reported by Gemini, if any, to
+// the session usage and the span
+func recordGeminiUsage(usage *usageTracker, span traceSpan, model string, meta *genai.GenerateContentResponseUsageMetadata) {
+	if meta == nil {
+		return
+	}
+	usage.add(model, int64(meta.PromptTokenCount), int64(meta.CandidatesTokenCount))
+	span.setTokens(int64(meta.PromptTokenCount), int64(meta.CandidatesTokenCount))
+}
+
+// flagsKey carries the flags of a translation under a context
+type flagsKey struct{}
+
+// translationFlags is set by backends that report why the model stopped,
+// when it wasn't the normal end of the answer
+type translationFlags struct {
+	reason string
+}
+
+// withTranslationFlags asks the backend under ctx to report an unusual
+// finish reason
+func withTranslationFlags(ctx context.Context) (context.Context, *translationFlags) {
+	flags := &translationFlags{}
+	return context.WithValue(ctx, flagsKey{}, flags), flags
+}
+
+// setFinishReason records the finish reason of a Gemini answer in the
+// flags under ctx, unless it is STOP or unknown
+func setFinishReason(ctx context.Context, reason genai.FinishReason) {
+	flags, ok := ctx.Value(flagsKey{}).(*translationFlags)
+	if !ok || reason == "" || reason == genai.FinishReasonStop || reason == genai.FinishReasonUnspecified {
+		return
+	}
+	flags.reason = string(reason)
+}
+
+// CandidatesTranslator is implemented by backends that can return several
+// alternative translations from one request, best first
+type CandidatesTranslator interface {
+	TranslateCandidates(ctx context.Context, prompt, text string, n int) ([]string, error)
+}
+
+// StreamingTranslator is implemented by backends that can deliver partial
+// output while the response is still being generated
+type StreamingTranslator interface {
+	TranslateStream(ctx context.Context, prompt, text string, chunks chan<- string) (string, error)
+}
+
+// TranslateStream sends each partial token over chunks as it arrives and
+// returns the full text once the stream finishes. chunks is closed on
+// return.
+func (g *GeminiTranslator) TranslateStream(ctx context.Context, prompt, text string, chunks chan<- string) (_ string, err error) {
+	defer close(chunks)
+	cfg := g.config()
+	ctx, span := startSpan(ctx, "gemini.generate_content_stream", cfg.Model, "")
+	defer func() { span.end(err) }()
+
+	index, client, err := g.client(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	var full strings.Builder
+	var meta *genai.GenerateContentResponseUsageMetadata
+	for result, err := range client.Models.GenerateContentStream(
+		ctx,
+		cfg.Model,
+		genai.Text(prompt+"\n\n"+text),
+		generateConfig(cfg),
+	) {
+		if err != nil {
+			if isRateLimited(err) {
+				g.keys.throttle(index)
+			}
+			return "", fmt.Errorf("generation failed: %w", err)
+		}
+		// Each chunk carries the running totals, so keep the latest
+		if result.UsageMetadata != nil {
+			meta = result.UsageMetadata
+		}
+		// The finish reason comes with the last chunk
+		if len(result.Candidates) > 0 {
+			setFinishReason(ctx, result.Candidates[0].FinishReason)
+		}
+		chunk := result.Text()
+		full.WriteString(chunk)
+		chunks <- chunk
+	}
+	recordGeminiUsage(g.usage, span, cfg.Model, meta)
+
+	return strings.TrimSpace(full.String()), nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"cmp"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const transliterationInstruction = "\n\nThe text was converted from Latin transliteration to Armenian letters automatically, so some words may be misspelled or still partly in Latin letters."
+
+// armenianTransliteration maps the common Latin spellings of Armenian
+// letters to the letters. Longer keys win over their prefixes.
+var armenianTransliteration = map[string]string{
+	"a": "ա", "b": "բ", "g": "գ", "d": "դ", "e": "ե", "z": "զ", "t": "տ",
+	"i": "ի", "l": "լ", "x": "խ", "c": "ց", "k": "կ", "h": "հ", "j": "ջ",
+	"m": "մ", "y": "յ", "n": "ն", "o": "ո", "p": "պ", "r": "ր", "s": "ս",
+	"v": "վ", "w": "ու", "u": "ու", "q": "ք", "f": "ֆ",
+	"sh": "շ", "ch": "չ", "zh": "ժ", "gh": "ղ", "kh": "խ", "ts": "ց",
+	"dz": "ձ", "ou": "ու",
+}
+
+// transliterableWord matches a whitespace separated word made of Latin
+// letters only, with optional punctuation around it. Emails, URLs and
+// placeholders such as __TERM_0__ or <EMAIL_1> never match.
+var transliterableWord = regexp.MustCompile(`^([("'«]*)([A-Za-z]+)([)"'».,!?;:]*)$`)
+
+var nonSpace = regexp.MustCompile(`\S+`)
+
+// transliterationTable returns the built-in table with the entries of
+// Config.TransliterationTable added or overriding it
+func transliterationTable(custom map[string]string) map[string]string {
+	table := maps.Clone(armenianTransliteration)
+	for k, v := range custom {
+		table[strings.ToLower(k)] = v
+	}
+	return table
+}
+
+// transliterateArmenian converts the Latin words of text to Armenian
+// letters through table. Words in capitals, such as acronyms, are kept.
+func transliterateArmenian(text string, table map[string]string) string {
+	keys := slices.Collect(maps.Keys(table))
+	slices.SortFunc(keys, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+
+	return nonSpace.ReplaceAllStringFunc(text, func(word string) string {
+		return transliterateWord(word, keys, table)
+	})
+}
+
+// transliterateWord converts a single word, or returns it unchanged when
+// it isn't a plain Latin word
+func transliterateWord(word string, keys []string, table map[string]string) string {
+	m := transliterableWord.FindStringSubmatch(word)
+	if m == nil || (len(m[2]) > 1 && strings.ToUpper(m[2]) == m[2]) {
+		return word
+	}
+
+	letters := m[2]
+	lower := strings.ToLower(letters)
+	var out strings.Builder
+	for pos := 0; pos < len(lower); {
+		matched := false
+		for _, k := range keys {
+			if k == "" || !strings.HasPrefix(lower[pos:], k) {
+				continue
+			}
+			value := table[k]
+			if unicode.IsUpper(rune(letters[pos])) {
+				r, size := utf8.DecodeRuneInString(value)
+				value = string(unicode.ToUpper(r)) + value[size:]
+			}
+			out.WriteString(value)
+			pos += len(k)
+			matched = true
+			break
+		}
+		if !matched {
+			out.WriteByte(letters[pos])
+			pos++
+		}
+	}
+	return m[1] + out.String() + m[3]
+}
@@ -0,0 +1,625 @@
+//go:build !headless
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/systray"
+	"github.com/atotto/clipboard"
+)
+
+// runTray shows the tray icon and blocks until "Quit" is chosen
+func (t *TranslatorApp) runTray() {
+	go t.shutdownOnSignal()
+	systray.Run(t.onTrayReady, func() {
+		t.stopHotkeyListener()
+	})
+}
+
+func (t *TranslatorApp) onTrayReady() {
+	setTrayState(t.enabled())
+	systray.SetTitle("LingoSnap")
+	systray.SetTooltip("LingoSnap")
+
+	cfg := t.currentConfig()
+
+	// Pinned prompts come right after the default one, each group in the
+	// order of prompts, and are repeated under Favorites
+	prompts := t.prompts()
+	selected := t.selectedIndex()
+	order := []int{0}
+	var pinned []int
+	for i, p := range prompts[1:] {
+		if p.Pinned {
+			pinned = append(pinned, i+1)
+		}
+	}
+	order = append(order, pinned...)
+	for i, p := range prompts[1:] {
+		if !p.Pinned {
+			order = append(order, i+1)
+		}
+	}
+
+	items := make([][]*systray.MenuItem, len(prompts)) // by prompt index
+	for _, i := range order {
+		title := prompts[i].Title
+		if prompts[i].Pinned {
+			title = "★ " + title
+		}
+		items[i] = append(items[i], systray.AddMenuItemCheckbox(title, "Use this prompt for "+cfg.Hotkey, i == selected))
+	}
+	if len(pinned) > 0 {
+		mFavorites := systray.AddMenuItem("Favorites", "Pinned prompts")
+		for _, i := range pinned {
+			items[i] = append(items[i], mFavorites.AddSubMenuItemCheckbox(prompts[i].Title, "Use this prompt for "+cfg.Hotkey, i == selected))
+		}
+	}
+	t.mu.Lock()
+	t.onPromptRename = func(i int, title string) {
+		for j, item := range items[i] {
+			if j == 0 && prompts[i].Pinned {
+				item.SetTitle("★ " + title)
+			} else {
+				item.SetTitle(title)
+			}
+		}
+	}
+	t.mu.Unlock()
+	for i := range items {
+		for _, item := range items[i] {
+			go func() {
+				for range item.ClickedCh {
+					t.selectPrompt(i)
+					for j, others := range items {
+						for _, other := range others {
+							if j == i {
+								other.Check()
+							} else {
+								other.Uncheck()
+							}
+						}
+					}
+				}
+			}()
+		}
+	}
+
+	systray.AddSeparator()
+	mPause := systray.AddMenuItem(pauseTitle(t.enabled()), "Ignore hotkeys and copied text until resumed")
+	t.mu.Lock()
+	t.onEnabledChange = func(enabled bool) {
+		setTrayState(enabled)
+		mPause.SetTitle(pauseTitle(enabled))
+	}
+	t.mu.Unlock()
+	mSettings := systray.AddMenuItem("Open Settings", "Edit settings.json")
+	mTestKey := systray.AddMenuItem("Test API Key", "Check that the key in settings.json works")
+	mPreProcess := systray.AddMenuItem("Advanced Pre-processing", "Clean up the text before it is translated")
+	for _, b := range builtinPreProcessRules {
+		item := mPreProcess.AddSubMenuItemCheckbox(b.label, "Applied before pre_process_rules", slices.Contains(cfg.PreProcessDefaults, b.name))
+		go func() {
+			for range item.ClickedCh {
+				on := !item.Checked()
+				if err := t.setPreProcessDefault(b.name, on); err != nil {
+					slog.Warn(fmt.Sprintf("⚠️  Failed to save config: %v", err))
+					continue
+				}
+				if on {
+					item.Check()
+				} else {
+					item.Uncheck()
+				}
+			}
+		}()
+	}
+	mAfterPaste := systray.AddMenuItem("After Pasting", "What the clipboard holds once a translation is pasted")
+	afterPasteItems := make([]*systray.MenuItem, len(afterPasteModes))
+	for i, m := range afterPasteModes {
+		afterPasteItems[i] = mAfterPaste.AddSubMenuItemCheckbox(m.label, "Applied after each paste", cfg.ClipboardAfterPaste == m.name)
+	}
+	for i, item := range afterPasteItems {
+		go func() {
+			for range item.ClickedCh {
+				t.setClipboardAfterPaste(afterPasteModes[i].name)
+				for j, other := range afterPasteItems {
+					if j == i {
+						other.Check()
+					} else {
+						other.Uncheck()
+					}
+				}
+			}
+		}()
+	}
+	mHotkey := systray.AddMenuItem("Disable Hotkey", "Stop listening for hotkeys")
+	mWatch := systray.AddMenuItemCheckbox("Translate Copied Text", "Translate text as soon as it is copied", t.watchingClipboard())
+	mDryRun := systray.AddMenuItemCheckbox("Dry Run", "Show the request a hotkey would send instead of calling the API", t.dryRunning())
+	mWindow := systray.AddMenuItem("New Translation Window", "Translate a text of its own with the selected prompt")
+	mEdit := systray.AddMenuItem("Edit Prompt…", "Edit the text of the selected prompt")
+	mQuickEdit := systray.AddMenuItem("Quick Edit Prompt…", "Change the title and first line of the selected prompt")
+	mUndo := systray.AddMenuItem("Undo Prompt Edit", "Restore the selected prompt's text from before its last edit")
+	mRedo := systray.AddMenuItem("Redo Prompt Edit", "Reapply the last undone edit of the selected prompt")
+	mShare := systray.AddMenuItem("Share Prompt via QR…", "Show a QR code of the selected prompt and copy its share code")
+	mImportShare := systray.AddMenuItem("Import Shared Prompt…", "Add a prompt from a pasted share code")
+	mVersions := systray.AddMenuItem("Prompt History…", "Compare the selected prompt with its earlier versions")
+	mBatch := systray.AddMenuItem("Batch File…", "Translate each paragraph of a text file")
+	mCSV := systray.AddMenuItem("Translate CSV…", "Translate chosen columns of a CSV file")
+	mLogin := systray.AddMenuItemCheckbox("Start at Login", "Start LingoSnap when you log in", t.startAtLogin())
+	mRecord := systray.AddMenuItem("Record Hotkey…", "Press a new key combination for the global hotkey")
+	mStats := systray.AddMenuItem("Statistics", "Show the most used language pairs")
+	mTMX := systray.AddMenuItem("Import Glossary from TMX…", "Add the terms of a translation memory to the glossary")
+	mExportBundle := systray.AddMenuItem("Export Config Bundle…", "Save the settings, history and plugins to a ZIP file for another machine")
+	mImportBundle := systray.AddMenuItem("Import Config Bundle…", "Apply the settings, history and plugins of a ZIP file")
+	mReport := systray.AddMenuItem("Report Bad Translation…", "Send feedback on the last translation")
+	mUsage := systray.AddMenuItem("Reset Session Usage", "Zero the session token counters")
+	mSpend := systray.AddMenuItem(spendTitle(0), "Estimated API spend this calendar month")
+	mSpend.Disable()
+	t.mu.Lock()
+	t.onSpendChange = func(spend float64) { mSpend.SetTitle(spendTitle(spend)) }
+	t.mu.Unlock()
+	if spend, err := t.monthlySpend(); err == nil {
+		t.showSpend(spend)
+	}
+	mCancel := systray.AddMenuItem("Cancel All", "Cancel the running and queued translations")
+	mCancel.Disable()
+	t.queue.setOnChange(func(pending int) {
+		if pending == 0 {
+			systray.SetTooltip("LingoSnap")
+			mCancel.Disable()
+			return
+		}
+		systray.SetTooltip(fmt.Sprintf("LingoSnap — Queue: %d pending", pending))
+		mCancel.Enable()
+	})
+	t.mu.Lock()
+	t.onRateLimitWait = func(wait time.Duration) {
+		if wait == 0 {
+			systray.SetTooltip("LingoSnap")
+			return
+		}
+		systray.SetTooltip(fmt.Sprintf("LingoSnap — Rate limited, retrying in %s", wait.Round(time.Second)))
+	}
+	t.limiter.setOnWait(t.onRateLimitWait)
+	t.mu.Unlock()
+	systray.AddSeparator()
+	mQuit := systray.AddMenuItem("Quit", "Quit LingoSnap")
+
+	go func() {
+		for {
+			select {
+			case <-mPause.ClickedCh:
+				t.setEnabled(!t.enabled())
+			case <-mSettings.ClickedCh:
+				openSettingsFile()
+			case <-mTestKey.ClickedCh:
+				go t.testAPIKey(mTestKey)
+			case <-mHotkey.ClickedCh:
+				if t.hotkeyListening() {
+					t.stopHotkeyListener()
+					mHotkey.SetTitle("Enable Hotkey")
+					log.Println("⏸  Hotkey disabled")
+				} else {
+					t.runHotkeyListener()
+					mHotkey.SetTitle("Disable Hotkey")
+					log.Println("▶ Hotkey enabled")
+				}
+			case <-mWatch.ClickedCh:
+				if t.watchingClipboard() {
+					t.setWatchClipboard(false)
+					mWatch.Uncheck()
+					log.Println("⏸  Clipboard watching disabled")
+				} else {
+					t.setWatchClipboard(true)
+					mWatch.Check()
+					log.Println("▶ Clipboard watching enabled")
+				}
+			case <-mDryRun.ClickedCh:
+				if t.dryRunning() {
+					t.setDryRun(false)
+					mDryRun.Uncheck()
+					log.Println("⏸  Dry run disabled")
+				} else {
+					t.setDryRun(true)
+					mDryRun.Check()
+					log.Println("▶ Dry run enabled")
+				}
+			case <-mBatch.ClickedCh:
+				go t.translateBatchFromTray()
+			case <-mCSV.ClickedCh:
+				go t.translateCSVFromTray()
+			case <-mLogin.ClickedCh:
+				enabled := !t.startAtLogin()
+				if err := t.setStartAtLogin(enabled); err != nil {
+					showWarning(err.Error())
+				} else if enabled {
+					mLogin.Check()
+				} else {
+					mLogin.Uncheck()
+				}
+			case <-mRecord.ClickedCh:
+				mRecord.SetTitle("Press keys…")
+				t.recordGlobalHotkey()
+				mRecord.SetTitle("Record Hotkey…")
+				mHotkey.SetTitle("Disable Hotkey")
+			case <-mWindow.ClickedCh:
+				go t.openTranslationWindow()
+			case <-mEdit.ClickedCh:
+				go t.editSelectedPrompt()
+			case <-mQuickEdit.ClickedCh:
+				go t.quickEditPrompt()
+			case <-mShare.ClickedCh:
+				go t.sharePromptFromTray()
+			case <-mImportShare.ClickedCh:
+				go t.importSharedPromptFromTray()
+			case <-mUndo.ClickedCh:
+				t.undoPromptEdit(false)
+			case <-mRedo.ClickedCh:
+				t.undoPromptEdit(true)
+			case <-mVersions.ClickedCh:
+				go t.showPromptHistory()
+			case <-mStats.ClickedCh:
+				go t.showStats()
+			case <-mTMX.ClickedCh:
+				go t.importTMXFromTray()
+			case <-mExportBundle.ClickedCh:
+				go t.exportBundleFromTray()
+			case <-mImportBundle.ClickedCh:
+				go t.importBundleFromTray()
+			case <-mReport.ClickedCh:
+				go t.reportLastTranslation()
+			case <-mUsage.ClickedCh:
+				t.usage.reset()
+				log.Println("🔄 Session usage reset")
+			case <-mCancel.ClickedCh:
+				log.Printf("⏹  Cancelled %d translations", t.queue.cancelAll())
+			case <-mQuit.ClickedCh:
+				mQuit.Disable()
+				t.shutdown()
+				systray.Quit()
+				return
+			}
+		}
+	}()
+}
+
+// setTrayState shows the normal icon, or a greyed-out one while
+// translation is paused
+func setTrayState(enabled bool) {
+	if enabled {
+		systray.SetIcon(trayIcon)
+	} else {
+		systray.SetIcon(trayIconPaused)
+	}
+}
+
+// pauseTitle is the label of the pause menu item
+func pauseTitle(enabled bool) string {
+	if enabled {
+		return "Pause Translation"
+	}
+	return "Resume Translation"
+}
+
+// translateCSVFromTray asks for a CSV file and the columns to translate,
+// offering all of them, and translates them with the selected prompt,
+// showing the progress in the tray tooltip
+func (t *TranslatorApp) translateCSVFromTray() {
+	path, ok := pickFile("Translate a CSV file", "*.csv")
+	if !ok {
+		return
+	}
+	header, err := readCSVHeader(path)
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+	spec, ok := askTextDefault("Translate CSV", "Columns to translate, separated by commas. Add :de and so on after a column for its own target language:", strings.Join(header, ", "))
+	if !ok {
+		return
+	}
+	columns, err := parseCSVColumns(spec)
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+
+	log.Printf("▶ Translating %s...", path)
+	out, err := t.translateCSVFile(path, t.selectedPrompt(), columns, func(done, total int) {
+		systray.SetTooltip(fmt.Sprintf("LingoSnap — CSV: %d/%d cells", done, total))
+	})
+	systray.SetTooltip("LingoSnap")
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+	log.Printf("✅ Translation written to %s", out)
+	go showDialog("LingoSnap", "Translation written to "+out, "OK", "", 0)
+}
+
+// translateBatchFromTray asks for a text file and batch translates it
+// with the selected prompt, showing the progress in the tray tooltip
+func (t *TranslatorApp) translateBatchFromTray() {
+	path, ok := pickFile("Translate a text file", "*.txt")
+	if !ok {
+		return
+	}
+	log.Printf("▶ Translating %s...", path)
+	out, err := t.translateBatchFile(path, t.selectedPrompt(), func(done, total int) {
+		systray.SetTooltip(fmt.Sprintf("LingoSnap — Batch: %d/%d", done, total))
+	})
+	systray.SetTooltip("LingoSnap")
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+	log.Printf("✅ Translation written to %s", out)
+	go showDialog("LingoSnap", "Translation written to "+out, "OK", "", 0)
+}
+
+// showStats shows the most used language pairs of this session and of all
+// time, and the latency histogram of all time, in a dialog. With several
+// models in the history, the latencies of one can be picked first.
+func (t *TranslatorApp) showStats() {
+	session, err := t.history.LanguagePairs(t.started, statsTopPairs)
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+	allTime, err := t.history.LanguagePairs(time.Time{}, statsTopPairs)
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+	latencies, err := t.history.Latencies(time.Time{}, "")
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+	if len(latencies) > 1 {
+		models := append([]string{"All models"}, slices.Sorted(maps.Keys(latencies))...)
+		i, ok := chooseText("LingoSnap Statistics", "Show the latencies of:", models)
+		if !ok {
+			return
+		}
+		if i > 0 {
+			latencies = map[string][]time.Duration{models[i]: latencies[models[i]]}
+		}
+	}
+	showDialog("LingoSnap Statistics",
+		"This session:\n"+formatPairChart(session)+"\n\nAll time:\n"+formatPairChart(allTime)+
+			"\n\nLatency:\n"+formatLatencyChart(latencies), "OK", "", 0)
+}
+
+// sharePromptFromTray copies the share code of the selected prompt to the
+// clipboard and opens its QR code in the image viewer
+func (t *TranslatorApp) sharePromptFromTray() {
+	i := t.selectedIndex()
+	if i == 0 {
+		showWarning("The default prompt is built in; select one of your prompts to share")
+		return
+	}
+	prompt := t.prompts()[i]
+	code := shareCode(prompt)
+	if err := clipboard.WriteAll(code); err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to copy the share code: %v", err))
+	}
+
+	f, err := os.CreateTemp("", "lingosnap-share-*.png")
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+	f.Close()
+	if err := writeQRCode(code, f.Name()); err != nil {
+		os.Remove(f.Name())
+		showWarning(err.Error() + "\n\nThe share code was copied to the clipboard instead.")
+		return
+	}
+	if err := openWithDefaultApp(f.Name()); err != nil {
+		showWarning("Failed to show the QR code: " + err.Error())
+		return
+	}
+	log.Printf("✅ Share code of %q copied, QR code in %s", prompt.Title, f.Name())
+}
+
+// importSharedPromptFromTray asks for a share code and adds its prompt
+func (t *TranslatorApp) importSharedPromptFromTray() {
+	code, ok := askText("Import Shared Prompt", "Paste the share code:")
+	if !ok {
+		return
+	}
+	var p Prompt
+	err := t.updateConfig(func(cfg *Config) error {
+		var err error
+		p, err = importShareCode(cfg, code)
+		return err
+	})
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+	log.Printf("✅ Imported %q", p.Title)
+	go showDialog("LingoSnap", fmt.Sprintf("Imported %q. Restart LingoSnap to see it in the menu.", p.Title), "OK", "", 0)
+}
+
+// importTMXFromTray asks for a TMX file and adds its terms into the
+// target language of the selected prompt to the glossary
+func (t *TranslatorApp) importTMXFromTray() {
+	path, ok := pickFile("Import a glossary from TMX", "*.tmx")
+	if !ok {
+		return
+	}
+	target := cmp.Or(t.selectedPrompt().TargetLang, defaultTargetLang)
+	domain, ok := askText("Import Glossary from TMX", "Only import terms of this domain (empty imports all):")
+	if !ok {
+		return
+	}
+
+	var n int
+	err := t.updateConfig(func(cfg *Config) error {
+		var err error
+		n, err = importTMX(cfg, path, "", target, domain)
+		return err
+	})
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+	log.Printf("✅ Added %d terms from %s", n, path)
+	go showDialog("LingoSnap", fmt.Sprintf("Added %d terms into %s to the glossary", n, target), "OK", "", 0)
+}
+
+// exportBundleFromTray asks for a passphrase and writes a config bundle
+// to the home folder
+func (t *TranslatorApp) exportBundleFromTray() {
+	passphrase, ok := askText("Export Config Bundle", "Passphrase to encrypt the API key with (empty leaves it out):")
+	if !ok {
+		return
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+	path := filepath.Join(home, "lingosnap-bundle-"+time.Now().Format("2006-01-02")+".zip")
+
+	if err := exportBundle(t.currentConfig(), t.history, path, passphrase); err != nil {
+		showWarning(err.Error())
+		return
+	}
+	log.Printf("✅ Exported the settings, history and plugins to %s", path)
+	go showDialog("LingoSnap", "Config bundle written to "+path, "OK", "", 0)
+}
+
+// importBundleFromTray asks for a config bundle, its passphrase and
+// whether to merge or replace, and applies it
+func (t *TranslatorApp) importBundleFromTray() {
+	path, ok := pickFile("Import a config bundle", "*.zip")
+	if !ok {
+		return
+	}
+	passphrase, ok := askText("Import Config Bundle", "Passphrase of the bundle:")
+	if !ok {
+		return
+	}
+	replace := !showDialog("LingoSnap",
+		"Merge the bundle's prompts, glossary and history into yours, or replace your settings and history with it?",
+		"Merge", "Replace", 0)
+	plugins, err := bundlePlugins(path)
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+	install := len(plugins) > 0 && showDialog("LingoSnap", pluginWarning(plugins), "Install Plugins", "Skip Plugins", 0)
+
+	// Translations running now keep reading the config they started with
+	err = t.updateConfig(func(cfg *Config) error {
+		return importBundle(cfg, t.history, path, passphrase, replace, install)
+	})
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+	log.Printf("✅ Imported %s", path)
+	go showDialog("LingoSnap", "Config bundle imported. Restart LingoSnap to apply every setting.", "OK", "", 0)
+}
+
+// recordGlobalHotkey captures a new global hotkey, saves it and re-registers
+// the hotkeys. The previous hotkey is kept if recording or saving fails.
+func (t *TranslatorApp) recordGlobalHotkey() {
+	t.stopHotkeyListener()
+	defer t.runHotkeyListener()
+
+	hotkey, err := recordHotkey(10 * time.Second)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Hotkey not recorded: %v", err))
+		return
+	}
+
+	if err := validateHotkey(hotkey); err != nil {
+		showWarning(fmt.Sprintf("Hotkey not changed: %v", err))
+		return
+	}
+	if isCommonShortcut(hotkey) && !showDialog("LingoSnap",
+		hotkey+" is a common shortcut in other applications. Use it anyway?", "Use it", "Cancel", 0) {
+		return
+	}
+
+	err = t.updateConfig(func(cfg *Config) error {
+		cfg.Hotkey = hotkey
+		return saveConfig(cfg)
+	})
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to save hotkey: %v", err))
+		return
+	}
+	log.Printf("✅ Hotkey set to %s", hotkey)
+}
+
+// openSettingsFile opens settings.json with the OS default handler
+func openSettingsFile() {
+	dir, err := configDir()
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  %v", err))
+		return
+	}
+	if err := openWithDefaultApp(filepath.Join(dir, configFileName)); err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Failed to open settings: %v", err))
+	}
+}
+
+// openWithDefaultApp opens path with the OS default handler, without
+// waiting for it to close
+func openWithDefaultApp(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	case "darwin":
+		cmd = exec.Command("open", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	return cmd.Start()
+}
+
+// reportLastTranslation asks what is wrong with the last hotkey
+// translation and sends it as feedback
+func (t *TranslatorApp) reportLastTranslation() {
+	t.mu.Lock()
+	prompt, original, translation := t.lastPrompt, t.lastInput, t.lastOutput
+	t.mu.Unlock()
+	if translation == "" {
+		showWarning("Nothing has been translated yet")
+		return
+	}
+
+	problem, ok := askText("Report Bad Translation", "What is wrong with the translation of “"+truncateText(original, 50)+"”?")
+	if !ok || strings.TrimSpace(problem) == "" {
+		return
+	}
+	include := showDialog("LingoSnap", "Include the original text, the translation and the prompt in the report?", "Include", "Leave Out", 0)
+
+	cfg := t.currentConfig()
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout(cfg, Prompt{}))
+	defer cancel()
+	if err := sendFeedback(ctx, cfg, newFeedbackReport(cfg, problem, original, translation, prompt, include)); err != nil {
+		showWarning(err.Error())
+		return
+	}
+	log.Println("✅ Feedback sent, thank you")
+}
@@ -0,0 +1,60 @@
+package main
+
+const (
+	// maxUndoSnapshots is how many edits of a prompt can be undone
+	maxUndoSnapshots = 50
+
+	// maxUndoBytes caps the text kept by an undo stack, so very long
+	// prompts don't pile up in memory
+	maxUndoBytes = 1 << 20
+)
+
+// undoStack keeps the earlier and undone texts of a prompt edited this
+// session
+type undoStack struct {
+	undo, redo []string
+}
+
+// record saves previous, the text before an edit. The undone edits are
+// forgotten.
+func (s *undoStack) record(previous string) {
+	s.undo = trimSnapshots(append(s.undo, previous))
+	s.redo = nil
+}
+
+// undoEdit returns the text before the last edit and keeps current for
+// redoEdit, or false when there is nothing to undo
+func (s *undoStack) undoEdit(current string) (string, bool) {
+	if len(s.undo) == 0 {
+		return "", false
+	}
+	text := s.undo[len(s.undo)-1]
+	s.undo = s.undo[:len(s.undo)-1]
+	s.redo = trimSnapshots(append(s.redo, current))
+	return text, true
+}
+
+// redoEdit returns the text of the last undone edit, or false when there
+// is nothing to redo
+func (s *undoStack) redoEdit(current string) (string, bool) {
+	if len(s.redo) == 0 {
+		return "", false
+	}
+	text := s.redo[len(s.redo)-1]
+	s.redo = s.redo[:len(s.redo)-1]
+	s.undo = trimSnapshots(append(s.undo, current))
+	return text, true
+}
+
+// trimSnapshots drops the oldest snapshots past maxUndoSnapshots or
+// maxUndoBytes
+func trimSnapshots(snapshots []string) []string {
+	size := 0
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		size += len(snapshots[i])
+		if len(snapshots)-i > maxUndoSnapshots || size > maxUndoBytes {
+			return snapshots[i+1:]
+		}
+	}
+	return snapshots
+}
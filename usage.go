@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// modelPrices holds the USD price per million input and output tokens
+var modelPrices = map[string]struct{ Input, Output float64 }{
+	"gemini-2.0-flash":      {0.10, 0.40},
+	"gemini-2.0-flash-lite": {0.075, 0.30},
+	"gemini-1.5-flash":      {0.075, 0.30},
+	"gemini-1.5-pro":        {1.25, 5.00},
+	"gpt-4o-mini":           {0.15, 0.60},
+	"gpt-4o":                {2.50, 10.00},
+	"gpt-4.1-mini":          {0.40, 1.60},
+}
+
+// UsageStats are the token totals for the current session
+type UsageStats struct {
+	InputTokens  int64
+	OutputTokens int64
+	CostUSD      float64
+	CacheHits    int64
+	CacheMisses  int64
+}
+
+func (s UsageStats) String() string {
+	summary := fmt.Sprintf("Session tokens: %d in / %d out — est. $%.4f", s.InputTokens, s.OutputTokens, s.CostUSD)
+	if s.CacheHits+s.CacheMisses > 0 {
+		summary += fmt.Sprintf(" — cache %d hits / %d misses", s.CacheHits, s.CacheMisses)
+	}
+	return summary
+}
+
+// usageTracker accumulates UsageStats across concurrent translations
+type usageTracker struct {
+	mu    sync.Mutex
+	stats UsageStats
+	store *HistoryStore // also records each call when set, for the budget
+}
+
+// add records a call; models missing from the price table count as free
+func (u *usageTracker) add(model string, input, output int64) {
+	price := modelPrices[model]
+	cost := (float64(input)*price.Input + float64(output)*price.Output) / 1e6
+
+	u.mu.Lock()
+	u.stats.InputTokens += input
+	u.stats.OutputTokens += output
+	u.stats.CostUSD += cost
+	store := u.store
+	u.mu.Unlock()
+
+	if store != nil {
+		if err := store.AddUsage(time.Now(), model, input, output, cost); err != nil {
+			slog.Warn(fmt.Sprintf("⚠️  %v", err))
+		}
+	}
+}
+
+// addCacheLookup records a translation cache hit or miss
+func (u *usageTracker) addCacheLookup(hit bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if hit {
+		u.stats.CacheHits++
+	} else {
+		u.stats.CacheMisses++
+	}
+}
+
+func (u *usageTracker) snapshot() UsageStats {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.stats
+}
+
+func (u *usageTracker) reset() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.stats = UsageStats{}
+}
+
+// GetUsageStats returns the token usage accumulated this session
+func (t *TranslatorApp) GetUsageStats() UsageStats {
+	return t.usage.snapshot()
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsAuthTimeout is how long a client has to answer the challenge
+const wsAuthTimeout = 10 * time.Second
+
+// wsRequest is a frame sent by a client: the answer to the challenge, or
+// a text to translate
+type wsRequest struct {
+	Auth        string `json:"auth,omitempty"`
+	Text        string `json:"text"`
+	PromptTitle string `json:"prompt_title"`
+	Stream      bool   `json:"stream"`
+}
+
+// wsFrame is a frame sent to a client. Done is only set on streamed
+// translations.
+type wsFrame struct {
+	Challenge string `json:"challenge,omitempty"`
+	Result    string `json:"result,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Chunk     string `json:"chunk,omitempty"`
+	Done      *bool  `json:"done,omitempty"`
+}
+
+var wsUpgrader = websocket.Upgrader{
+	// Browser extensions connect from their own origin; clients are
+	// authenticated by the challenge instead
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// serveWebsocket serves live translations over a WebSocket on addr.
+//
+// On connect the server sends {"challenge":"<nonce>"} and the client
+// answers {"auth":"<hex HMAC-SHA256 of the nonce keyed with
+// websocket_token>"}. Then each {"text":"...","prompt_title":"..."} frame
+// is answered with {"result":"..."} or {"error":"..."}. With "stream":true
+// the text arrives as {"chunk":"...","done":false} frames first, and the
+// result frame has "done":true.
+func (t *TranslatorApp) serveWebsocket(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /", t.handleWebsocket)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("🌐 Serving live translations on ws://%s", addr)
+	if err := srv.ListenAndServe(); err != nil {
+		slog.Error(fmt.Sprintf("❌ WebSocket server failed: %v", err))
+	}
+}
+
+func (t *TranslatorApp) handleWebsocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already answered with an HTTP error
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(1 << 20)
+
+	if !t.authenticateWebsocket(conn) {
+		conn.WriteJSON(wsFrame{Error: "authentication failed"})
+		return
+	}
+
+	for {
+		var req wsRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				slog.Warn(fmt.Sprintf("⚠️  WebSocket client left: %v", err))
+			}
+			return
+		}
+		if err := conn.WriteJSON(t.translateWebsocket(r.Context(), conn, req)); err != nil {
+			return
+		}
+	}
+}
+
+// authenticateWebsocket sends a random challenge and checks the client's
+// answer against Config.WebsocketToken
+func (t *TranslatorApp) authenticateWebsocket(conn *websocket.Conn) bool {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		slog.Error(fmt.Sprintf("❌ Failed to create challenge: %v", err))
+		return false
+	}
+	challenge := hex.EncodeToString(nonce)
+	if err := conn.WriteJSON(wsFrame{Challenge: challenge}); err != nil {
+		return false
+	}
+
+	conn.SetReadDeadline(time.Now().Add(wsAuthTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	var req wsRequest
+	if err := conn.ReadJSON(&req); err != nil {
+		return false
+	}
+	answer, err := hex.DecodeString(req.Auth)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(t.currentConfig().WebsocketToken))
+	mac.Write([]byte(challenge))
+	return hmac.Equal(answer, mac.Sum(nil))
+}
+
+// translateWebsocket translates one request and returns the frame to
+// answer with. Streamed chunks are written to conn as they arrive.
+func (t *TranslatorApp) translateWebsocket(ctx context.Context, conn *websocket.Conn, req wsRequest) wsFrame {
+	if strings.TrimSpace(req.Text) == "" {
+		return wsFrame{Error: "text is required"}
+	}
+	prompt := t.selectedPrompt()
+	if req.PromptTitle != "" {
+		var ok bool
+		if prompt, ok = t.findPrompt(req.PromptTitle); !ok {
+			return wsFrame{Error: "no prompt titled " + req.PromptTitle}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, translationTimeout(t.currentConfig(), prompt))
+	defer cancel()
+	if req.Stream {
+		notDone := false
+		ctx = withChunkSink(ctx, func(chunk string) {
+			conn.WriteJSON(wsFrame{Chunk: chunk, Done: &notDone})
+		})
+	}
+
+	result, err := t.translateText(ctx, prompt, req.Text)
+	if err != nil {
+		slog.Error(fmt.Sprintf("❌ WebSocket translation failed: %v", err))
+		return wsFrame{Error: err.Error()}
+	}
+	frame := wsFrame{Result: result}
+	if req.Stream {
+		done := true
+		frame.Done = &done
+	}
+	return frame
+}
@@ -0,0 +1,54 @@
+//go:build !headless
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+
+	"github.com/atotto/clipboard"
+)
+
+// openTranslationWindow opens a window to translate a text of its own with
+// the selected prompt. It starts with the clipboard text and runs
+// alongside the hotkey and other windows, up to Config.MaxWindows at once.
+func (t *TranslatorApp) openTranslationWindow() {
+	t.mu.Lock()
+	if t.openWindows >= max(t.config.MaxWindows, 1) {
+		t.mu.Unlock()
+		showWarning("Close a translation window first")
+		return
+	}
+	t.openWindows++
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.openWindows--
+		t.mu.Unlock()
+	}()
+
+	prompt := t.selectedPrompt()
+	title := "LingoSnap — " + prompt.Title
+	initial, _ := clipboard.ReadAll()
+	text, ok := editText(title, initial)
+	if !ok || strings.TrimSpace(text) == "" {
+		return
+	}
+
+	log.Printf("▶ Translating in a window with %q...", prompt.Title)
+	ctx, cancel := context.WithTimeout(context.Background(), translationTimeout(t.currentConfig(), prompt))
+	defer cancel()
+	translated, err := t.translateText(ctx, prompt, text)
+	if err != nil {
+		showWarning(err.Error())
+		return
+	}
+	if showDialog(title, translated, "Copy", "Close", 0) {
+		if err := clipboard.WriteAll(translated); err != nil {
+			slog.Error(fmt.Sprintf("❌ Failed to write to clipboard: %v", err))
+		}
+	}
+}